@@ -1,16 +1,36 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/zenrot/CryptoService/internal/auth/internalAuth"
+	"github.com/zenrot/CryptoService/internal/config"
 	"github.com/zenrot/CryptoService/internal/config/configYaml"
 	httpServer "github.com/zenrot/CryptoService/internal/http-server"
+	"github.com/zenrot/CryptoService/internal/priceAlerts"
+	"github.com/zenrot/CryptoService/internal/priceSource"
+	"github.com/zenrot/CryptoService/internal/priceSource/binanceSource"
+	"github.com/zenrot/CryptoService/internal/priceSource/coingeckoSource"
+	"github.com/zenrot/CryptoService/internal/priceSource/consensusSource"
+	"github.com/zenrot/CryptoService/internal/priceSource/fakeSource"
+	"github.com/zenrot/CryptoService/internal/priceSource/krakenSource"
+	"github.com/zenrot/CryptoService/internal/priceStream"
 	"github.com/zenrot/CryptoService/internal/priceUpdater/priceUpdaterMultithreaded"
 	"github.com/zenrot/CryptoService/internal/storage"
 	"github.com/zenrot/CryptoService/internal/storage/postgresStorage"
 	"github.com/zenrot/CryptoService/internal/storage/ramstore"
+	"github.com/zenrot/CryptoService/internal/storage/sqliteStorage"
+	"github.com/zenrot/CryptoService/internal/watchlistManager"
+	"github.com/zenrot/CryptoService/internal/webhookDispatcher"
 )
 
 var configPath string
@@ -19,46 +39,149 @@ func init() {
 	flag.StringVar(&configPath, "configPath", "config/config.yaml", "provide path to the config file")
 }
 
-func main() {
-	cfg := configYaml.MustLoad(configPath)
+func newSingleSource(name string, cfg *config.Config) (priceSource.PriceSource, error) {
+	switch name {
+	case "fake":
+		return fakeSource.New(), nil
+	case "binance":
+		return binanceSource.New(cfg), nil
+	case "kraken":
+		return krakenSource.New(cfg), nil
+	case "coingecko", "":
+		return coingeckoSource.New(cfg), nil
+	}
+	return nil, fmt.Errorf("unknown price source %q", name)
+}
 
-	if cfg.StorageType == "postgres" {
-		var storeCrypto storage.Crypto
-		var storeAuth storage.Auth
-		var err error
-		storeCrypto, err = postgresStorage.NewCrypto(cfg)
-		storeAuth, err = postgresStorage.NewAuth(cfg)
+func newPriceSource(cfg *config.Config) (priceSource.PriceSource, error) {
+	if cfg.SourceType != "consensus" {
+		return newSingleSource(cfg.SourceType, cfg)
+	}
+	consensus := consensusSource.New(cfg)
+	for _, name := range cfg.Sources {
+		source, err := newSingleSource(strings.TrimSpace(name), cfg)
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
-		pu := priceUpdaterMultithreaded.New(cfg, storeCrypto)
+		consensus.AddSource(strings.TrimSpace(name), source)
+	}
+	return consensus, nil
+}
 
-		auth := internalAuth.New(storeAuth, cfg.JwtKey)
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 || (args[0] != "up" && args[0] != "down" && args[0] != "status") {
+		return fmt.Errorf("usage: migrate up|down [steps]|status")
+	}
+	m, err := postgresStorage.NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
 
-		serv := httpServer.New(cfg, storeCrypto, pu, auth)
-		serv.Start()
-	} else {
-		var store storage.AuthCrypto
-		store, err := ramstore.NewRamStorage()
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		n, err := m.Up(ctx)
 		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d migration(s)\n", n)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid steps: %s", args[1])
+			}
+		}
+		n, err := m.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("rolled back %d migration(s)\n", n)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, st := range statuses {
+			state := "pending"
+			if st.Applied {
+				state = "applied " + st.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%s\t%s\n", st.Version, st.Name, state)
+		}
+	}
+	return nil
+}
+
+func main() {
+	flag.Parse()
+	cfg := configYaml.MustLoad(configPath)
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(cfg, flag.Args()[1:]); err != nil {
 			log.Fatal(err)
 		}
-		pu := priceUpdaterMultithreaded.New(cfg, store)
+		return
+	}
+
+	source, err := newPriceSource(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var store storage.AuthCrypto
+	switch cfg.StorageType {
+	case "postgres":
+		store, err = postgresStorage.NewPostgresStorage(cfg)
+	case "sqlite":
+		store, err = sqliteStorage.NewSQLiteStorage(cfg)
+	default:
+		store, err = ramstore.NewRamStorage(cfg)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
 
-		auth := internalAuth.New(store, cfg.JwtKey)
+	hub := priceStream.New(cfg.StreamBuffer)
+	engine, err := priceAlerts.New(cfg, store)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dispatcher, err := webhookDispatcher.New(cfg, store)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pu := priceUpdaterMultithreaded.New(cfg, engine.Evaluating(hub.Publishing(dispatcher.Notifying(store))), source)
+	dispatcher.WatchHealth(pu)
+	lists, err := watchlistManager.New(cfg, store, pu)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-		serv := httpServer.New(cfg, store, pu, auth)
-		serv.Start()
+	auth := internalAuth.New(store, cfg.JwtKey)
+
+	serv := httpServer.New(cfg, store, lists.Tracking(), auth, hub, engine, dispatcher, lists)
+	if err := serv.Start(ctx); err != nil {
+		log.Fatal(err)
 	}
 
-	//if err = storage.RegisterUser("leh", "1234"); err != nil {
-	//	log.Fatal(err)
-	//}
-	//
-	//user, err := storage.LoginUser("leh", "1234")
-	//if err != nil {
-	//	log.Fatal(err)
-	//}
-	//fmt.Println(user)
+	<-ctx.Done()
+	stop()
+	log.Println("shutting down")
 
+	timeout := cfg.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := serv.Shutdown(shutdownCtx); err != nil {
+		log.Println(err)
+	}
+	if err := store.Close(); err != nil {
+		log.Println(err)
+	}
 }
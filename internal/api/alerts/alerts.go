@@ -0,0 +1,75 @@
+package alerts
+
+import (
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+type Request struct {
+	Symbol          string  `json:"symbol" binding:"required"`
+	Currency        string  `json:"currency"`
+	Type            string  `json:"type" binding:"required"`
+	Threshold       float64 `json:"threshold"`
+	WindowSeconds   int     `json:"window_seconds"`
+	CooldownSeconds int     `json:"cooldown_seconds"`
+}
+
+type Response struct {
+	ID              string  `json:"id"`
+	Symbol          string  `json:"symbol"`
+	Currency        string  `json:"currency"`
+	Type            string  `json:"type"`
+	Threshold       float64 `json:"threshold"`
+	WindowSeconds   int     `json:"window_seconds"`
+	CooldownSeconds int     `json:"cooldown_seconds"`
+	Triggered       bool    `json:"triggered"`
+	ChangedAt       string  `json:"changed_at"`
+	CreatedAt       string  `json:"created_at"`
+}
+
+type EventResponse struct {
+	State   string  `json:"state"`
+	Price   float64 `json:"price"`
+	Message string  `json:"message"`
+	Time    string  `json:"time"`
+}
+
+func (req Request) Rule(user string) storage.AlertRule {
+	return storage.AlertRule{
+		User:      user,
+		Symbol:    req.Symbol,
+		Currency:  req.Currency,
+		Type:      req.Type,
+		Threshold: req.Threshold,
+		Window:    time.Duration(req.WindowSeconds) * time.Second,
+		Cooldown:  time.Duration(req.CooldownSeconds) * time.Second,
+	}
+}
+
+func NewResponse(rule storage.AlertRule) Response {
+	resp := Response{
+		ID:              rule.ID,
+		Symbol:          rule.Symbol,
+		Currency:        rule.Currency,
+		Type:            rule.Type,
+		Threshold:       rule.Threshold,
+		WindowSeconds:   int(rule.Window / time.Second),
+		CooldownSeconds: int(rule.Cooldown / time.Second),
+		Triggered:       rule.Triggered,
+		CreatedAt:       rule.CreatedAt.Format(time.RFC3339),
+	}
+	if !rule.ChangedAt.IsZero() {
+		resp.ChangedAt = rule.ChangedAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+func NewEventResponse(event storage.AlertEvent) EventResponse {
+	return EventResponse{
+		State:   event.State,
+		Price:   event.Price,
+		Message: event.Message,
+		Time:    event.Time.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,26 @@
+package deleteAlerts
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/api/middleware/authMiddleware"
+	"github.com/zenrot/CryptoService/internal/priceAlerts"
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func AlertDeleteHandler(engine *priceAlerts.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := engine.Delete(authMiddleware.User(c), c.Param("id"))
+		if errors.Is(err, storage.ErrAlertNotExists) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{})
+	}
+}
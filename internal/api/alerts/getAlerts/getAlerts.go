@@ -0,0 +1,57 @@
+package getAlerts
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/api/alerts"
+	"github.com/zenrot/CryptoService/internal/api/middleware/authMiddleware"
+	"github.com/zenrot/CryptoService/internal/priceAlerts"
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+const (
+	defaultEventsLimit = 100
+	maxEventsLimit     = 1000
+)
+
+func AlertsGetHandler(engine *priceAlerts.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rules := engine.Rules(authMiddleware.User(c))
+		res := make([]alerts.Response, len(rules))
+		for i, rule := range rules {
+			res[i] = alerts.NewResponse(rule)
+		}
+		c.JSON(http.StatusOK, gin.H{"alerts": res})
+	}
+}
+
+func AlertEventsGetHandler(engine *priceAlerts.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := defaultEventsLimit
+		if raw := c.Query("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxEventsLimit {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxEventsLimit)})
+				return
+			}
+			limit = n
+		}
+		events, err := engine.Events(authMiddleware.User(c), c.Param("id"), limit)
+		if errors.Is(err, storage.ErrAlertNotExists) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		res := make([]alerts.EventResponse, len(events))
+		for i, event := range events {
+			res[i] = alerts.NewEventResponse(event)
+		}
+		c.JSON(http.StatusOK, gin.H{"events": res})
+	}
+}
@@ -0,0 +1,34 @@
+package postAlerts
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/api/alerts"
+	"github.com/zenrot/CryptoService/internal/api/middleware/authMiddleware"
+	"github.com/zenrot/CryptoService/internal/priceAlerts"
+)
+
+func AlertsPostHandler(engine *priceAlerts.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req alerts.Request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		rule, err := engine.Create(req.Rule(authMiddleware.User(c)))
+		switch {
+		case errors.Is(err, priceAlerts.ErrInvalidRule):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, priceAlerts.ErrTooManyRules):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, alerts.NewResponse(rule))
+	}
+}
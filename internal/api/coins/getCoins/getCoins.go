@@ -0,0 +1,40 @@
+package getCoins
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
+)
+
+type responseCoin struct {
+	ID            string `json:"id"`
+	Symbol        string `json:"symbol"`
+	Name          string `json:"name"`
+	MarketCapRank int    `json:"market_cap_rank"`
+}
+
+func CoinsSearchGetHandler(updater priceUpdater.PriceUpdater) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Query("q")
+		if query == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "query parameter q is required"})
+			return
+		}
+		coins, err := updater.SearchCoins(query)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+		res := make([]responseCoin, len(coins))
+		for i, coin := range coins {
+			res[i] = responseCoin{
+				ID:            coin.ID,
+				Symbol:        coin.Symbol,
+				Name:          coin.Name,
+				MarketCapRank: coin.MarketCapRank,
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"coins": res})
+	}
+}
@@ -0,0 +1,67 @@
+package deleteCrypto
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/api/crypto/postCrypto"
+	"github.com/zenrot/CryptoService/internal/priceSource"
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
+	"github.com/zenrot/CryptoService/internal/storage"
+	"github.com/zenrot/CryptoService/internal/watchlistManager"
+)
+
+const (
+	StatusDeleted    = "deleted"
+	StatusNotTracked = "not_tracked"
+	StatusWatched    = "watched"
+	StatusError      = "error"
+)
+
+func CryptoBatchDeleteHandler(store storage.Crypto, updater priceUpdater.PriceUpdater) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		items, ok := postCrypto.BatchItems(c)
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			items[i] = deleteItem(store, updater, item)
+		}
+		postCrypto.RespondBatch(c, items)
+	}
+}
+
+func deleteItem(store storage.Crypto, updater priceUpdater.PriceUpdater, item postCrypto.BatchResult) postCrypto.BatchResult {
+	if item.CoinID != "" {
+		coin, err := updater.DeleteCryptoTrackingByID(item.CoinID)
+		if err != nil {
+			item.Status, item.Error = notDeletedStatus(err), err.Error()
+			return item
+		}
+		item.Symbol, item.Name = coin.Symbol, coin.Name
+	} else if err := updater.DeleteCryptoTracking(item.Symbol); err != nil {
+		item.Status, item.Error = notDeletedStatus(err), err.Error()
+		return item
+	}
+
+	// A coin removed before its first price was stored has no history.
+	if err := store.DeleteCrypto(item.Symbol); err != nil && !errors.Is(err, storage.ErrCryptoNotExists) {
+		item.Status, item.Error = StatusError, err.Error()
+		return item
+	}
+	item.Status = StatusDeleted
+	return item
+}
+
+// notDeletedStatus classifies an updater error. Only a coin the updater does
+// not track (or, by ID, one the source does not know) is reported as
+// not_tracked; anything else is a failure.
+func notDeletedStatus(err error) string {
+	switch {
+	case errors.Is(err, watchlistManager.ErrWatched):
+		return StatusWatched
+	case errors.Is(err, priceUpdater.ErrNotTracked), errors.Is(err, priceSource.ErrUnknownCoin):
+		return StatusNotTracked
+	}
+	return StatusError
+}
@@ -0,0 +1,51 @@
+package deleteCrypto
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/zenrot/CryptoService/internal/api/crypto/postCrypto"
+	"github.com/zenrot/CryptoService/internal/priceSource"
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
+	"github.com/zenrot/CryptoService/internal/storage"
+	"github.com/zenrot/CryptoService/internal/watchlistManager"
+)
+
+// failingUpdater fails every untrack call with err.
+type failingUpdater struct {
+	priceUpdater.PriceUpdater
+	err error
+}
+
+func (fu failingUpdater) DeleteCryptoTracking(symbol string) error {
+	return fu.err
+}
+
+func (fu failingUpdater) DeleteCryptoTrackingByID(ID string) (priceSource.CoinInfo, error) {
+	return priceSource.CoinInfo{}, fu.err
+}
+
+func TestDeleteItemStatus(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		item postCrypto.BatchResult
+		want string
+	}{
+		{"not tracked", fmt.Errorf("symbol BTC is %w", priceUpdater.ErrNotTracked), postCrypto.BatchResult{Symbol: "BTC"}, StatusNotTracked},
+		{"unknown id", fmt.Errorf("%w: nope", priceSource.ErrUnknownCoin), postCrypto.BatchResult{CoinID: "nope"}, StatusNotTracked},
+		{"watched", fmt.Errorf("%w: BTC", watchlistManager.ErrWatched), postCrypto.BatchResult{Symbol: "BTC"}, StatusWatched},
+		{"updater failure", errors.New("storage is down"), postCrypto.BatchResult{Symbol: "BTC"}, StatusError},
+		{"updater failure by id", priceUpdater.ErrStopped, postCrypto.BatchResult{CoinID: "bitcoin"}, StatusError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var store storage.Crypto
+			got := deleteItem(store, failingUpdater{err: tc.err}, tc.item)
+			if got.Status != tc.want || got.Error != tc.err.Error() {
+				t.Errorf("deleteItem = %+v, want status %q with error %q", got, tc.want, tc.err)
+			}
+		})
+	}
+}
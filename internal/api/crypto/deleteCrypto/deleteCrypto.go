@@ -1,21 +1,27 @@
 package deleteCrypto
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/zenrot/CryptoService/internal/priceUpdater"
 	"github.com/zenrot/CryptoService/internal/storage"
+	"github.com/zenrot/CryptoService/internal/watchlistManager"
 	"net/http"
 )
 
 func CryptoDeleteSymbolHandler(store storage.Crypto, updater priceUpdater.PriceUpdater) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		symbol := c.Param("symbol")
-		if err := updater.DeleteCryptoTracking(symbol); err != nil {
+		if err := updater.DeleteCryptoTracking(symbol); errors.Is(err, watchlistManager.ErrWatched) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		} else if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
-		if err := store.DeleteCrypto(symbol); err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		// A coin removed before its first price was stored has no history.
+		if err := store.DeleteCrypto(symbol); err != nil && !errors.Is(err, storage.ErrCryptoNotExists) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{})
@@ -0,0 +1,67 @@
+package getCrypto
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+var candleIntervals = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+type responseCandle struct {
+	Time        string  `json:"time"`
+	Open        float64 `json:"open"`
+	High        float64 `json:"high"`
+	Low         float64 `json:"low"`
+	Close       float64 `json:"close"`
+	SampleCount int     `json:"sample_count"`
+}
+
+func CryptoSymbolGetCandlesHandler(store storage.Crypto) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		symbol := c.Param("symbol")
+		currency := CurrencyParam(c)
+		name := c.DefaultQuery("interval", "1h")
+		interval, ok := candleIntervals[name]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be one of 1m, 5m, 15m, 1h, 1d"})
+			return
+		}
+		from, to, err := timeRange(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		candles, err := store.GetCandles(symbol, currency, interval, from, to)
+		if err != nil {
+			if errors.Is(err, storage.ErrCryptoNotExists) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		resp := make([]responseCandle, 0, len(candles))
+		for _, v := range candles {
+			resp = append(resp, responseCandle{
+				Time:        v.Time.Format(time.RFC3339),
+				Open:        v.Open,
+				High:        v.High,
+				Low:         v.Low,
+				Close:       v.Close,
+				SampleCount: v.SampleCount,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"symbol": symbol, "currency": currency, "interval": name, "candles": resp})
+	}
+}
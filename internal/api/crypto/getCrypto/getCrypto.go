@@ -1,10 +1,13 @@
 package getCrypto
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
 	"github.com/zenrot/CryptoService/internal/storage"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -12,19 +15,33 @@ import (
 type ResponseCrypto struct {
 	Symbol       string  `json:"symbol"`
 	Name         string  `json:"name"`
+	Currency     string  `json:"currency"`
 	CurrentPrice float64 `json:"current_price"`
 	LastUpdated  string  `json:"last_updated"`
 }
 
+type responseCryptoStatus struct {
+	ResponseCrypto
+	Status    string `json:"status"`
+	LastError string `json:"last_error"`
+	Stale     bool   `json:"stale"`
+}
+
+func CurrencyParam(c *gin.Context) string {
+	return strings.ToLower(c.DefaultQuery("currency", storage.DefaultCurrency))
+}
+
 func CryptoGetHandler(store storage.Crypto) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		val, _ := store.GetLatestCrypto()
+		currency := CurrencyParam(c)
+		val, _ := store.GetLatestCrypto(currency)
 		res := make([]ResponseCrypto, len(val))
 		i := 0
 		for _, v := range val {
 			res[i] = ResponseCrypto{
 				Symbol:       v.Symbol,
 				Name:         v.Name,
+				Currency:     v.Currency,
 				CurrentPrice: v.Price,
 				LastUpdated:  v.Time.Format(time.RFC3339),
 			}
@@ -34,23 +51,41 @@ func CryptoGetHandler(store storage.Crypto) gin.HandlerFunc {
 	}
 }
 
-func CryptoSymbolGetHandler(store storage.Crypto) gin.HandlerFunc {
+func CryptoSymbolGetHandler(store storage.Crypto, updater priceUpdater.PriceUpdater) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		symbol := c.Param("symbol")
-		val, _ := store.GetLatestCrypto()
-		if v, ok := val[symbol]; !ok {
+		currency := CurrencyParam(c)
+		val, _ := store.GetLatestCrypto(currency)
+		v, hasPrice := val[symbol]
+		health, err := updater.GetHealth(symbol)
+		if !hasPrice && err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Errorf("symbol %s is not being tracked", symbol).Error()})
-		} else {
-			var resp ResponseCrypto
-			resp = ResponseCrypto{
+			return
+		}
+
+		resp := responseCryptoStatus{
+			ResponseCrypto: ResponseCrypto{
+				Symbol:   symbol,
+				Currency: currency,
+			},
+			Status:    health.Status,
+			LastError: health.LastError,
+			Stale:     health.Stale,
+		}
+		if hasPrice {
+			resp.ResponseCrypto = ResponseCrypto{
 				Symbol:       v.Symbol,
 				Name:         v.Name,
+				Currency:     v.Currency,
 				CurrentPrice: v.Price,
 				LastUpdated:  v.Time.Format(time.RFC3339),
 			}
-
-			c.JSON(http.StatusOK, resp)
 		}
+		if err != nil {
+			resp.Status = "untracked"
+			resp.Stale = true
+		}
+		c.JSON(http.StatusOK, resp)
 	}
 }
 
@@ -59,26 +94,72 @@ type responseGetHistory struct {
 	Time  string  `json:"timestamp"`
 }
 
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+func timeRange(c *gin.Context) (from, to time.Time, err error) {
+	if val := c.Query("from"); val != "" {
+		if from, err = time.Parse(time.RFC3339, val); err != nil {
+			return from, to, fmt.Errorf("invalid from: %s", val)
+		}
+	}
+	if val := c.Query("to"); val != "" {
+		if to, err = time.Parse(time.RFC3339, val); err != nil {
+			return from, to, fmt.Errorf("invalid to: %s", val)
+		}
+	}
+	return from, to, nil
+}
+
+func historyQuery(c *gin.Context) (storage.HistoryQuery, error) {
+	query := storage.HistoryQuery{
+		Limit:  defaultHistoryLimit,
+		Cursor: c.Query("cursor"),
+	}
+	var err error
+	if query.From, query.To, err = timeRange(c); err != nil {
+		return query, err
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil || query.Limit < 1 || query.Limit > maxHistoryLimit {
+			return query, fmt.Errorf("limit must be between 1 and %d", maxHistoryLimit)
+		}
+	}
+	return query, nil
+}
+
 func CryptoSymbolGetHistoryHandler(store storage.Crypto) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		symbol := c.Param("symbol")
+		currency := CurrencyParam(c)
+		query, err := historyQuery(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
-		if val, err := store.GetCrypto(symbol); err != nil {
-			if strings.HasPrefix(err.Error(), fmt.Sprintf("symbol %s is not being tracked", symbol)) {
+		if page, err := store.GetCrypto(symbol, currency, query); err != nil {
+			if errors.Is(err, storage.ErrInvalidCursor) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, storage.ErrCryptoNotExists) {
 				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 				return
 			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		} else {
-			var resp []responseGetHistory
-			for _, v := range val {
+			resp := make([]responseGetHistory, 0, len(page.Values))
+			for _, v := range page.Values {
 				resp = append(resp, responseGetHistory{
 					Price: v.Price,
 					Time:  v.Time.Format(time.RFC3339),
 				})
 			}
-			c.JSON(http.StatusOK, gin.H{"symbol": symbol, "history": resp})
+			c.JSON(http.StatusOK, gin.H{"symbol": symbol, "currency": currency, "history": resp, "next_cursor": page.NextCursor})
 		}
 	}
 }
@@ -86,16 +167,22 @@ func CryptoSymbolGetHistoryHandler(store storage.Crypto) gin.HandlerFunc {
 func CryptoSymbolGetStatsHandler(store storage.Crypto) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		symbol := c.Param("symbol")
-		if val, err := store.GetCryptoStats(symbol); err != nil {
-			if strings.HasPrefix(err.Error(), fmt.Sprintf("symbol %s is not being tracked", symbol)) {
+		currency := CurrencyParam(c)
+		from, to, err := timeRange(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if val, err := store.GetCryptoStats(symbol, currency, from, to); err != nil {
+			if errors.Is(err, storage.ErrCryptoNotExists) || errors.Is(err, storage.ErrNoRecords) {
 				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 				return
 			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		} else {
-			v, _ := store.GetLatestCrypto()
-			c.JSON(http.StatusOK, gin.H{"symbol": symbol, "current_price": v[symbol].Price, "stats": val})
+			v, _ := store.GetLatestCrypto(currency)
+			c.JSON(http.StatusOK, gin.H{"symbol": symbol, "currency": currency, "current_price": v[symbol].Price, "stats": val})
 		}
 	}
 }
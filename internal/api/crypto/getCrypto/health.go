@@ -0,0 +1,69 @@
+package getCrypto
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
+)
+
+type responseHealth struct {
+	Symbol              string `json:"symbol"`
+	Status              string `json:"status"`
+	LastSuccess         string `json:"last_success"`
+	LastError           string `json:"last_error"`
+	LastErrorTime       string `json:"last_error_time"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	Stale               bool   `json:"stale"`
+}
+
+type responseHealthSummary struct {
+	Total   int              `json:"total"`
+	OK      int              `json:"ok"`
+	Failing int              `json:"failing"`
+	Pending int              `json:"pending"`
+	Stale   int              `json:"stale"`
+	Coins   []responseHealth `json:"coins"`
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func CryptoHealthGetHandler(updater priceUpdater.PriceUpdater) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp := responseHealthSummary{Coins: make([]responseHealth, 0)}
+		for symbol, h := range updater.GetHealths() {
+			switch h.Status {
+			case priceUpdater.StatusOK:
+				resp.OK++
+			case priceUpdater.StatusFailing:
+				resp.Failing++
+			case priceUpdater.StatusPending:
+				resp.Pending++
+			}
+			if h.Stale {
+				resp.Stale++
+			}
+			resp.Coins = append(resp.Coins, responseHealth{
+				Symbol:              symbol,
+				Status:              h.Status,
+				LastSuccess:         formatTime(h.LastSuccess),
+				LastError:           h.LastError,
+				LastErrorTime:       formatTime(h.LastErrorTime),
+				ConsecutiveFailures: h.ConsecutiveFailures,
+				Stale:               h.Stale,
+			})
+		}
+		resp.Total = len(resp.Coins)
+		sort.Slice(resp.Coins, func(i, j int) bool {
+			return resp.Coins[i].Symbol < resp.Coins[j].Symbol
+		})
+		c.JSON(http.StatusOK, resp)
+	}
+}
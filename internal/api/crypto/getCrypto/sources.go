@@ -0,0 +1,36 @@
+package getCrypto
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/priceSource"
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func CryptoSymbolGetSourcesHandler(store storage.Crypto, updater priceUpdater.PriceUpdater) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		symbol := c.Param("symbol")
+		currency := CurrencyParam(c)
+		quotes, err := updater.GetSourceQuotes(symbol)
+		if err != nil {
+			if errors.Is(err, priceUpdater.ErrNotTracked) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		res := make([]priceSource.SourceQuote, 0, len(quotes))
+		for _, q := range quotes {
+			if q.Currency == currency {
+				res = append(res, q)
+			}
+		}
+		v, _ := store.GetLatestCrypto(currency)
+		c.JSON(http.StatusOK, gin.H{"symbol": symbol, "currency": currency, "consensus_price": v[symbol].Price, "sources": res})
+	}
+}
@@ -0,0 +1,136 @@
+package postCrypto
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/priceSource"
+	"github.com/zenrot/CryptoService/internal/priceSource/providerClient"
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
+)
+
+const (
+	MaxBatchSize = 100
+	batchWorkers = 8
+)
+
+const (
+	StatusCreated        = "created"
+	StatusAlreadyTracked = "already_tracked"
+	StatusNotFound       = "not_found"
+	StatusProviderError  = "provider_error"
+)
+
+type requestBatch struct {
+	Symbols []string `json:"symbols"`
+	CoinIDs []string `json:"coin_ids"`
+}
+
+type BatchResult struct {
+	Symbol string `json:"symbol,omitempty"`
+	CoinID string `json:"coin_id,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchItems validates a batch request body and returns its items with
+// duplicates removed, symbols first.
+func BatchItems(c *gin.Context) ([]BatchResult, bool) {
+	var req requestBatch
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	items := make([]BatchResult, 0, len(req.Symbols)+len(req.CoinIDs))
+	seen := make(map[BatchResult]bool)
+	for _, symbol := range req.Symbols {
+		item := BatchResult{Symbol: strings.TrimSpace(symbol)}
+		if item.Symbol != "" && !seen[item] {
+			seen[item] = true
+			items = append(items, item)
+		}
+	}
+	for _, id := range req.CoinIDs {
+		item := BatchResult{CoinID: strings.TrimSpace(id)}
+		if item.CoinID != "" && !seen[item] {
+			seen[item] = true
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "symbols or coin_ids must not be empty"})
+		return nil, false
+	}
+	if len(items) > MaxBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many items in batch, max 100"})
+		return nil, false
+	}
+	return items, true
+}
+
+// RespondBatch writes per-item results with 207 Multi-Status.
+func RespondBatch(c *gin.Context, results []BatchResult) {
+	summary := make(map[string]int)
+	for _, res := range results {
+		summary[res.Status]++
+	}
+	c.JSON(http.StatusMultiStatus, gin.H{"results": results, "summary": summary})
+}
+
+func CryptoBatchPostHandler(updater priceUpdater.PriceUpdater) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		items, ok := BatchItems(c)
+		if !ok {
+			return
+		}
+
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < min(batchWorkers, len(items)); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					items[i] = addItem(updater, items[i])
+				}
+			}()
+		}
+		for i := range items {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+
+		RespondBatch(c, items)
+	}
+}
+
+func addItem(updater priceUpdater.PriceUpdater, item BatchResult) BatchResult {
+	var coin priceSource.CoinInfo
+	var err error
+	if item.CoinID != "" {
+		coin, err = updater.AddCryptoTrackingByID(item.CoinID)
+	} else {
+		coin, err = updater.AddCryptoTracking(item.Symbol)
+	}
+	if err == nil {
+		return BatchResult{Symbol: coin.Symbol, CoinID: coin.ID, Name: coin.Name, Status: StatusCreated}
+	}
+
+	item.Error = err.Error()
+	var statusErr *providerClient.StatusError
+	switch {
+	case errors.Is(err, priceUpdater.ErrAlreadyTracked):
+		item.Status = StatusAlreadyTracked
+	case errors.Is(err, priceSource.ErrUnknownCoin),
+		errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound:
+		item.Status = StatusNotFound
+	default:
+		item.Status = StatusProviderError
+	}
+	return item
+}
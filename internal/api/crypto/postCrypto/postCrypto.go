@@ -1,17 +1,19 @@
 package postCrypto
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/zenrot/CryptoService/internal/api/crypto/getCrypto"
+	"github.com/zenrot/CryptoService/internal/priceSource"
 	"github.com/zenrot/CryptoService/internal/priceUpdater"
 	"github.com/zenrot/CryptoService/internal/storage"
 	"net/http"
-	"strings"
 	"time"
 )
 
 type requestPostCrypto struct {
 	Symbol string `json:"symbol"`
+	CoinID string `json:"coin_id"`
 }
 
 func CryptoPostHandler(store storage.Crypto, updater priceUpdater.PriceUpdater) gin.HandlerFunc {
@@ -21,25 +23,42 @@ func CryptoPostHandler(store storage.Crypto, updater priceUpdater.PriceUpdater)
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		if err := updater.AddCryptoTracking(req.Symbol); err != nil {
-			if strings.HasPrefix(err.Error(), "this coin already exists") {
+		if (req.Symbol == "") == (req.CoinID == "") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of symbol or coin_id must be provided"})
+			return
+		}
+
+		var coin priceSource.CoinInfo
+		var err error
+		if req.CoinID != "" {
+			coin, err = updater.AddCryptoTrackingByID(req.CoinID)
+		} else {
+			coin, err = updater.AddCryptoTracking(req.Symbol)
+		}
+		if err != nil {
+			if errors.Is(err, priceUpdater.ErrAlreadyTracked) {
 				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 				return
 			}
+			if errors.Is(err, priceSource.ErrUnknownCoin) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		val, _ := store.GetLatestCrypto()
-		v := val[req.Symbol]
+		val, _ := store.GetLatestCrypto(getCrypto.CurrencyParam(c))
+		v := val[coin.Symbol]
 		var resp getCrypto.ResponseCrypto
 
 		resp = getCrypto.ResponseCrypto{
-			Symbol:       v.Symbol,
-			Name:         v.Name,
+			Symbol:       coin.Symbol,
+			Name:         coin.Name,
+			Currency:     v.Currency,
 			CurrentPrice: v.Price,
 			LastUpdated:  v.Time.Format(time.RFC3339),
 		}
 
-		c.JSON(http.StatusCreated, gin.H{"crypto": resp})
+		c.JSON(http.StatusCreated, gin.H{"crypto": resp, "coin_id": coin.ID})
 	}
 }
@@ -24,11 +24,12 @@ func CryptoPutSymbolRefresh(store storage.Crypto, updater priceUpdater.PriceUpda
 			return
 		}
 
-		val, _ := store.GetLatestCrypto()
+		val, _ := store.GetLatestCrypto(getCrypto.CurrencyParam(c))
 		var resp getCrypto.ResponseCrypto
 		resp = getCrypto.ResponseCrypto{
 			Symbol:       val[symbol].Symbol,
 			Name:         val[symbol].Name,
+			Currency:     val[symbol].Currency,
 			CurrentPrice: val[symbol].Price,
 			LastUpdated:  val[symbol].Time.Format(time.RFC3339),
 		}
@@ -0,0 +1,77 @@
+package streamCrypto
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/api/crypto/getCrypto"
+	"github.com/zenrot/CryptoService/internal/priceStream"
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+const defaultHeartbeat = 15 * time.Second
+
+type responseHeartbeat struct {
+	Time    string `json:"time"`
+	Dropped int64  `json:"dropped"`
+}
+
+func symbolsParam(c *gin.Context) []string {
+	var res []string
+	for _, symbol := range strings.Split(c.Query("symbols"), ",") {
+		if symbol = strings.TrimSpace(symbol); symbol != "" {
+			res = append(res, symbol)
+		}
+	}
+	return res
+}
+
+func priceResponse(v storage.CryptoVal) getCrypto.ResponseCrypto {
+	return getCrypto.ResponseCrypto{
+		Symbol:       v.Symbol,
+		Name:         v.Name,
+		Currency:     v.Currency,
+		CurrentPrice: v.Price,
+		LastUpdated:  v.Time.Format(time.RFC3339),
+	}
+}
+
+func heartbeatResponse(sub *priceStream.Subscription) responseHeartbeat {
+	return responseHeartbeat{Time: time.Now().Format(time.RFC3339), Dropped: sub.Dropped()}
+}
+
+func CryptoStreamSSEHandler(hub *priceStream.Hub, heartbeat time.Duration) gin.HandlerFunc {
+	if heartbeat <= 0 {
+		heartbeat = defaultHeartbeat
+	}
+	return func(c *gin.Context) {
+		sub := hub.Subscribe(symbolsParam(c), getCrypto.CurrencyParam(c))
+		defer sub.Close()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case v, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				c.SSEvent("price", priceResponse(v))
+			case <-ticker.C:
+				c.SSEvent("heartbeat", heartbeatResponse(sub))
+			}
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package streamCrypto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/zenrot/CryptoService/internal/api/crypto/getCrypto"
+	"github.com/zenrot/CryptoService/internal/priceStream"
+)
+
+const (
+	writeWait       = 10 * time.Second
+	maxRequestBytes = 4096
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+type requestWS struct {
+	Action  string   `json:"action"`
+	Symbols []string `json:"symbols"`
+}
+
+type responseWS struct {
+	Type      string                    `json:"type"`
+	Price     *getCrypto.ResponseCrypto `json:"price,omitempty"`
+	Heartbeat *responseHeartbeat        `json:"heartbeat,omitempty"`
+	Symbols   []string                  `json:"symbols,omitempty"`
+	Error     string                    `json:"error,omitempty"`
+}
+
+func CryptoStreamWSHandler(hub *priceStream.Hub, heartbeat time.Duration) gin.HandlerFunc {
+	if heartbeat <= 0 {
+		heartbeat = defaultHeartbeat
+	}
+	return func(c *gin.Context) {
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		sub := hub.Subscribe(symbolsParam(c), getCrypto.CurrencyParam(c))
+		defer sub.Close()
+
+		replies := make(chan responseWS, 8)
+		done := make(chan struct{})
+		go readRequests(conn, sub, 2*heartbeat, replies, done)
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			var msg responseWS
+			select {
+			case <-done:
+				return
+			case v, ok := <-sub.C:
+				if !ok {
+					conn.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down"),
+						time.Now().Add(writeWait))
+					return
+				}
+				price := priceResponse(v)
+				msg = responseWS{Type: "price", Price: &price}
+			case msg = <-replies:
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+					return
+				}
+				hb := heartbeatResponse(sub)
+				msg = responseWS{Type: "heartbeat", Heartbeat: &hb}
+			}
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteJSON(msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readRequests applies subscribe/unsubscribe requests until the connection
+// fails or stays silent (no pong either) for longer than idle.
+func readRequests(conn *websocket.Conn, sub *priceStream.Subscription, idle time.Duration,
+	replies chan<- responseWS, done chan<- struct{}) {
+	defer close(done)
+	conn.SetReadLimit(maxRequestBytes)
+	conn.SetReadDeadline(time.Now().Add(idle))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(idle))
+	})
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(idle))
+
+		var req requestWS
+		reply := responseWS{Type: "subscribed"}
+		if err := json.Unmarshal(data, &req); err != nil {
+			reply = responseWS{Type: "error", Error: err.Error()}
+		} else {
+			switch req.Action {
+			case "subscribe":
+				sub.Add(req.Symbols...)
+				reply.Symbols = sub.Symbols()
+			case "unsubscribe":
+				sub.Remove(req.Symbols...)
+				reply.Symbols = sub.Symbols()
+			default:
+				reply = responseWS{Type: "error", Error: "action must be subscribe or unsubscribe"}
+			}
+		}
+		select {
+		case replies <- reply:
+		default:
+		}
+	}
+}
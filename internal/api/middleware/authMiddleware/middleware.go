@@ -9,6 +9,10 @@ import (
 	"github.com/zenrot/CryptoService/internal/auth"
 )
 
+// UserKey is the context key under which AuthMiddleware stores the name of
+// the authorized user.
+const UserKey = "username"
+
 func AuthMiddleware(auth auth.Authorizer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -19,12 +23,35 @@ func AuthMiddleware(auth auth.Authorizer) gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		err := auth.AuthorizeUser(authToken)
+		user, err := auth.AuthorizeUser(authToken)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
+		c.Set(UserKey, user)
+		c.Next()
+	}
+}
+
+// User returns the name of the user authorized by AuthMiddleware.
+func User(c *gin.Context) string {
+	return c.GetString(UserKey)
+}
+
+// TokenQueryMiddleware lets clients that cannot set headers (EventSource,
+// browser WebSocket) pass the JWT as the access_token query parameter. The
+// parameter is removed from the request URL once copied into the header.
+func TokenQueryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Request.URL.Query()
+		if token := query.Get("access_token"); token != "" {
+			if c.GetHeader("Authorization") == "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+			query.Del("access_token")
+			c.Request.URL.RawQuery = query.Encode()
+		}
 		c.Next()
 	}
 }
@@ -1,33 +1,61 @@
 package getSchedule
 
 import (
-	"github.com/gin-gonic/gin"
-	"github.com/zenrot/CryptoService/internal/api/schedule"
-	"github.com/zenrot/CryptoService/internal/priceUpdater"
+	"errors"
 	"net/http"
+	"sort"
 	"strconv"
 	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/api/schedule"
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
 )
 
 func ScheduleGetHandler(updater priceUpdater.PriceUpdater) func(c *gin.Context) {
 	return func(c *gin.Context) {
-
-		if val := updater.GetUpdateTime(); val == time.Duration(-1) {
-			resp := schedule.Response{
+		var resp schedule.ListResponse
+		if val := updater.GetUpdateTime(); val == 0 {
+			resp.Response = schedule.Response{
 				Enabled:         false,
 				IntervalSeconds: "0",
 				LastUpdated:     updater.GetLastUpdated().Format(time.RFC3339),
 				NextUpdate:      "Never",
 			}
-			c.JSON(http.StatusOK, resp)
 		} else {
-			resp := schedule.Response{
+			resp.Response = schedule.Response{
 				Enabled:         true,
 				IntervalSeconds: strconv.Itoa(int(updater.GetUpdateTime() / time.Second)),
 				LastUpdated:     updater.GetLastUpdated().Format(time.RFC3339),
 				NextUpdate:      updater.GetLastUpdated().Add(updater.GetUpdateTime()).Format(time.RFC3339),
 			}
-			c.JSON(http.StatusOK, resp)
 		}
+
+		resp.Overrides = make([]schedule.SymbolResponse, 0)
+		for symbol, s := range updater.GetSchedules() {
+			if s.Custom {
+				resp.Overrides = append(resp.Overrides, schedule.NewSymbolResponse(symbol, s))
+			}
+		}
+		sort.Slice(resp.Overrides, func(i, j int) bool {
+			return resp.Overrides[i].Symbol < resp.Overrides[j].Symbol
+		})
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+func SymbolScheduleGetHandler(updater priceUpdater.PriceUpdater) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		symbol := c.Param("symbol")
+		s, err := updater.GetSchedule(symbol)
+		if err != nil {
+			if errors.Is(err, priceUpdater.ErrNotTracked) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, schedule.NewSymbolResponse(symbol, s))
 	}
 }
@@ -1,11 +1,13 @@
 package putSchedule
 
 import (
+	"errors"
+	"net/http"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/zenrot/CryptoService/internal/api/schedule"
 	"github.com/zenrot/CryptoService/internal/priceUpdater"
-	"net/http"
-	"time"
 )
 
 func SchedulePutHandler(updater priceUpdater.PriceUpdater) gin.HandlerFunc {
@@ -22,7 +24,7 @@ func SchedulePutHandler(updater priceUpdater.PriceUpdater) gin.HandlerFunc {
 				return
 			}
 
-			if err := updater.ChangeUpdateTime(time.Duration(req.IntervalSeconds)); err != nil {
+			if err := updater.ChangeUpdateTime(time.Duration(req.IntervalSeconds) * time.Second); err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
 			}
@@ -37,3 +39,30 @@ func SchedulePutHandler(updater priceUpdater.PriceUpdater) gin.HandlerFunc {
 
 	}
 }
+
+func SymbolSchedulePutHandler(updater priceUpdater.PriceUpdater) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		symbol := c.Param("symbol")
+		var req schedule.Request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Enabled && (req.IntervalSeconds < 10 || req.IntervalSeconds > 3600) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "interval seconds must be between 10 and 3600"})
+			return
+		}
+
+		if err := updater.SetSchedule(symbol, req.Enabled, time.Duration(req.IntervalSeconds)*time.Second); err != nil {
+			if errors.Is(err, priceUpdater.ErrNotTracked) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		s, _ := updater.GetSchedule(symbol)
+		c.JSON(http.StatusOK, schedule.NewSymbolResponse(symbol, s))
+	}
+}
@@ -1,5 +1,12 @@
 package schedule
 
+import (
+	"strconv"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
+)
+
 type Request struct {
 	Enabled         bool `json:"enabled"`
 	IntervalSeconds int  `json:"interval_seconds"`
@@ -11,3 +18,32 @@ type Response struct {
 	LastUpdated     string `json:"last_update"`
 	NextUpdate      string `json:"next_update"`
 }
+
+type SymbolResponse struct {
+	Symbol string `json:"symbol"`
+	Custom bool   `json:"custom"`
+	Response
+}
+
+type ListResponse struct {
+	Response
+	Overrides []SymbolResponse `json:"overrides"`
+}
+
+func NewSymbolResponse(symbol string, s priceUpdater.Schedule) SymbolResponse {
+	resp := SymbolResponse{
+		Symbol: symbol,
+		Custom: s.Custom,
+		Response: Response{
+			Enabled:         s.Enabled,
+			IntervalSeconds: strconv.Itoa(int(s.Interval / time.Second)),
+			LastUpdated:     s.LastUpdated.Format(time.RFC3339),
+			NextUpdate:      s.NextUpdate.Format(time.RFC3339),
+		},
+	}
+	if !s.Enabled {
+		resp.IntervalSeconds = "0"
+		resp.NextUpdate = "Never"
+	}
+	return resp
+}
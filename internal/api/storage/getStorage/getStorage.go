@@ -0,0 +1,19 @@
+package getStorage
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func StorageMemoryGetHandler(store storage.Crypto) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reporter, ok := store.(storage.MemoryReporter)
+		if !ok {
+			c.JSON(http.StatusNotImplemented, gin.H{"error": "storage does not keep history in memory"})
+			return
+		}
+		c.JSON(http.StatusOK, reporter.MemoryUsage())
+	}
+}
@@ -0,0 +1,31 @@
+package deleteWatchlists
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/api/middleware/authMiddleware"
+	"github.com/zenrot/CryptoService/internal/api/watchlists"
+	"github.com/zenrot/CryptoService/internal/watchlistManager"
+)
+
+func WatchlistDeleteHandler(manager *watchlistManager.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := manager.Delete(authMiddleware.User(c), c.Param("id")); err != nil {
+			c.JSON(watchlists.ErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{})
+	}
+}
+
+func WatchlistSymbolDeleteHandler(manager *watchlistManager.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, err := manager.RemoveSymbol(authMiddleware.User(c), c.Param("id"), c.Param("symbol"))
+		if err != nil {
+			c.JSON(watchlists.ErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, watchlists.NewResponse(list))
+	}
+}
@@ -0,0 +1,52 @@
+package getWatchlists
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/api/crypto/getCrypto"
+	"github.com/zenrot/CryptoService/internal/api/middleware/authMiddleware"
+	"github.com/zenrot/CryptoService/internal/api/watchlists"
+	"github.com/zenrot/CryptoService/internal/storage"
+	"github.com/zenrot/CryptoService/internal/watchlistManager"
+)
+
+func WatchlistsGetHandler(manager *watchlistManager.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lists := manager.Lists(authMiddleware.User(c))
+		res := make([]watchlists.Response, len(lists))
+		for i, list := range lists {
+			res[i] = watchlists.NewResponse(list)
+		}
+		c.JSON(http.StatusOK, gin.H{"watchlists": res})
+	}
+}
+
+// WatchlistGetHandler returns the list with the latest prices of its
+// symbols, in list order; symbols without a price yet are omitted.
+func WatchlistGetHandler(manager *watchlistManager.Manager, store storage.Crypto) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, err := manager.Get(authMiddleware.User(c), c.Param("id"))
+		if err != nil {
+			c.JSON(watchlists.ErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		val, _ := store.GetLatestCrypto(getCrypto.CurrencyParam(c))
+		cryptos := make([]getCrypto.ResponseCrypto, 0, len(list.Symbols))
+		for _, symbol := range list.Symbols {
+			v, ok := val[symbol]
+			if !ok {
+				continue
+			}
+			cryptos = append(cryptos, getCrypto.ResponseCrypto{
+				Symbol:       v.Symbol,
+				Name:         v.Name,
+				Currency:     v.Currency,
+				CurrentPrice: v.Price,
+				LastUpdated:  v.Time.Format(time.RFC3339),
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"watchlist": watchlists.NewResponse(list), "cryptos": cryptos})
+	}
+}
@@ -0,0 +1,42 @@
+package postWatchlists
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/api/middleware/authMiddleware"
+	"github.com/zenrot/CryptoService/internal/api/watchlists"
+	"github.com/zenrot/CryptoService/internal/watchlistManager"
+)
+
+func WatchlistsPostHandler(manager *watchlistManager.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req watchlists.Request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		list, err := manager.Create(authMiddleware.User(c), req.Name, req.Symbols)
+		if err != nil {
+			c.JSON(watchlists.ErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, watchlists.NewResponse(list))
+	}
+}
+
+func WatchlistSymbolsPostHandler(manager *watchlistManager.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req watchlists.SymbolsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		list, err := manager.AddSymbols(authMiddleware.User(c), c.Param("id"), req.Symbols)
+		if err != nil {
+			c.JSON(watchlists.ErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, watchlists.NewResponse(list))
+	}
+}
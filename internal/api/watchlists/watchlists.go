@@ -0,0 +1,54 @@
+package watchlists
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/priceSource"
+	"github.com/zenrot/CryptoService/internal/storage"
+	"github.com/zenrot/CryptoService/internal/watchlistManager"
+)
+
+type Request struct {
+	Name    string   `json:"name" binding:"required"`
+	Symbols []string `json:"symbols"`
+}
+
+type SymbolsRequest struct {
+	Symbols []string `json:"symbols" binding:"required"`
+}
+
+type Response struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Symbols   []string `json:"symbols"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+func NewResponse(list storage.Watchlist) Response {
+	return Response{
+		ID:        list.ID,
+		Name:      list.Name,
+		Symbols:   list.Symbols,
+		CreatedAt: list.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: list.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// ErrorStatus maps a watchlistManager error to its HTTP status.
+func ErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, watchlistManager.ErrInvalidWatchlist):
+		return http.StatusBadRequest
+	case errors.Is(err, storage.ErrWatchlistNotExists),
+		errors.Is(err, watchlistManager.ErrNotInWatchlist),
+		errors.Is(err, priceSource.ErrUnknownCoin):
+		return http.StatusNotFound
+	case errors.Is(err, watchlistManager.ErrWatchlistExists),
+		errors.Is(err, watchlistManager.ErrTooManyWatchlists):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,26 @@
+package deleteWebhooks
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/api/middleware/authMiddleware"
+	"github.com/zenrot/CryptoService/internal/storage"
+	"github.com/zenrot/CryptoService/internal/webhookDispatcher"
+)
+
+func WebhookDeleteHandler(dispatcher *webhookDispatcher.Dispatcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := dispatcher.Delete(authMiddleware.User(c), c.Param("id"))
+		if errors.Is(err, storage.ErrWebhookNotExists) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{})
+	}
+}
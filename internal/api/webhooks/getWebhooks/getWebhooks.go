@@ -0,0 +1,64 @@
+package getWebhooks
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/api/middleware/authMiddleware"
+	"github.com/zenrot/CryptoService/internal/api/webhooks"
+	"github.com/zenrot/CryptoService/internal/storage"
+	"github.com/zenrot/CryptoService/internal/webhookDispatcher"
+)
+
+const (
+	defaultDeliveriesLimit = 100
+	maxDeliveriesLimit     = 1000
+)
+
+func WebhooksGetHandler(dispatcher *webhookDispatcher.Dispatcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hooks := dispatcher.Webhooks(authMiddleware.User(c))
+		res := make([]webhooks.Response, len(hooks))
+		for i, hook := range hooks {
+			res[i] = webhooks.NewResponse(hook)
+		}
+		c.JSON(http.StatusOK, gin.H{"webhooks": res})
+	}
+}
+
+func WebhookDeliveriesGetHandler(dispatcher *webhookDispatcher.Dispatcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := c.Query("status")
+		switch status {
+		case "", storage.DeliveryPending, storage.DeliveryDelivered, storage.DeliveryDead:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, delivered or dead"})
+			return
+		}
+		limit := defaultDeliveriesLimit
+		if raw := c.Query("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxDeliveriesLimit {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxDeliveriesLimit)})
+				return
+			}
+			limit = n
+		}
+		deliveries, err := dispatcher.Deliveries(authMiddleware.User(c), c.Param("id"), status, limit)
+		if errors.Is(err, storage.ErrWebhookNotExists) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		res := make([]webhooks.DeliveryResponse, len(deliveries))
+		for i, delivery := range deliveries {
+			res[i] = webhooks.NewDeliveryResponse(delivery)
+		}
+		c.JSON(http.StatusOK, gin.H{"deliveries": res})
+	}
+}
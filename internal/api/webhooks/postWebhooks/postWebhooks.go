@@ -0,0 +1,34 @@
+package postWebhooks
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/api/middleware/authMiddleware"
+	"github.com/zenrot/CryptoService/internal/api/webhooks"
+	"github.com/zenrot/CryptoService/internal/webhookDispatcher"
+)
+
+func WebhooksPostHandler(dispatcher *webhookDispatcher.Dispatcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req webhooks.Request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		hook, err := dispatcher.Create(req.Webhook(authMiddleware.User(c)))
+		switch {
+		case errors.Is(err, webhookDispatcher.ErrInvalidWebhook):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, webhookDispatcher.ErrTooManyWebhooks):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, webhooks.CreatedResponse{Response: webhooks.NewResponse(hook), Secret: hook.Secret})
+	}
+}
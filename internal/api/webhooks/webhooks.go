@@ -0,0 +1,81 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+type Request struct {
+	URL     string   `json:"url" binding:"required"`
+	Events  []string `json:"events"`
+	Symbols []string `json:"symbols"`
+	Secret  string   `json:"secret"`
+}
+
+type Response struct {
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	Symbols   []string `json:"symbols"`
+	CreatedAt string   `json:"created_at"`
+}
+
+// CreatedResponse is returned once on creation; the secret is not shown
+// afterwards.
+type CreatedResponse struct {
+	Response
+	Secret string `json:"secret"`
+}
+
+type DeliveryResponse struct {
+	ID           string          `json:"id"`
+	Event        string          `json:"event"`
+	Status       string          `json:"status"`
+	Attempts     int             `json:"attempts"`
+	NextAttempt  string          `json:"next_attempt,omitempty"`
+	LastError    string          `json:"last_error"`
+	ResponseCode int             `json:"response_code"`
+	CreatedAt    string          `json:"created_at"`
+	UpdatedAt    string          `json:"updated_at"`
+	Payload      json.RawMessage `json:"payload"`
+}
+
+func (req Request) Webhook(user string) storage.Webhook {
+	return storage.Webhook{
+		User:    user,
+		URL:     req.URL,
+		Secret:  req.Secret,
+		Events:  req.Events,
+		Symbols: req.Symbols,
+	}
+}
+
+func NewResponse(hook storage.Webhook) Response {
+	return Response{
+		ID:        hook.ID,
+		URL:       hook.URL,
+		Events:    hook.Events,
+		Symbols:   hook.Symbols,
+		CreatedAt: hook.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func NewDeliveryResponse(delivery storage.WebhookDelivery) DeliveryResponse {
+	resp := DeliveryResponse{
+		ID:           delivery.ID,
+		Event:        delivery.Event,
+		Status:       delivery.Status,
+		Attempts:     delivery.Attempts,
+		LastError:    delivery.LastError,
+		ResponseCode: delivery.ResponseCode,
+		CreatedAt:    delivery.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:    delivery.UpdatedAt.Format(time.RFC3339),
+		Payload:      json.RawMessage(delivery.Payload),
+	}
+	if delivery.Status == storage.DeliveryPending {
+		resp.NextAttempt = delivery.NextAttempt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -3,5 +3,5 @@ package auth
 type Authorizer interface {
 	AuthenticateUser(name, password string) (string, error)
 	RegisterUser(name, password string) (string, error)
-	AuthorizeUser(tokenString string) error
+	AuthorizeUser(tokenString string) (string, error)
 }
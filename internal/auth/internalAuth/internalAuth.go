@@ -54,9 +54,10 @@ func (au *internalAuthorizer) RegisterUser(name, password string) (string, error
 	return au.AuthenticateUser(name, password)
 }
 
-func (au *internalAuthorizer) AuthorizeUser(tokenString string) error {
+func (au *internalAuthorizer) AuthorizeUser(tokenString string) (string, error) {
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+	var claims internalCustomClaims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
@@ -64,12 +65,12 @@ func (au *internalAuthorizer) AuthorizeUser(tokenString string) error {
 	})
 
 	if err != nil {
-		return fmt.Errorf("invalid token: %v", err)
+		return "", fmt.Errorf("invalid token: %v", err)
 	}
 
-	if _, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return nil
+	if token.Valid && claims.Username != "" {
+		return claims.Username, nil
 	}
 
-	return fmt.Errorf("invalid token claims")
+	return "", fmt.Errorf("invalid token claims")
 }
@@ -1,21 +1,104 @@
 package config
 
+import "time"
+
 type Config struct {
-	CoingeckoKey   string `yaml:"coingeckoKey" required:"true"`
-	StorageType    string `yaml:"storage_type" default:"ram"`
-	AuthorizerType string `yaml:"authorizer_type" default:"internal"`
-	HttpConfig     `yaml:"http-config"`
-	PostgresConfig `yaml:"postgres-storage"`
+	CoingeckoKey      string `yaml:"coingeckoKey" required:"true"`
+	StorageType       string `yaml:"storage_type" default:"ram"`
+	AuthorizerType    string `yaml:"authorizer_type" default:"internal"`
+	HttpConfig        `yaml:"http-config"`
+	PostgresConfig    `yaml:"postgres-storage"`
+	RamStorageConfig  `yaml:"ram-storage"`
+	SQLiteConfig      `yaml:"sqlite-storage"`
+	PriceSourceConfig `yaml:"price-source"`
+	AlertsConfig      `yaml:"alerts"`
+	WebhooksConfig    `yaml:"webhooks"`
+	WatchlistsConfig  `yaml:"watchlists"`
 }
 
 type PostgresConfig struct {
-	Host     string `yaml:"host"`
-	Port     string `yaml:"port"`
-	User     string `yaml:"user"`
-	Password string `yaml:"password"`
-	Dbname   string `yaml:"dbname"`
+	DSN              string        `yaml:"dsn"`
+	Host             string        `yaml:"host"`
+	Port             string        `yaml:"port"`
+	User             string        `yaml:"user"`
+	Password         string        `yaml:"password"`
+	Dbname           string        `yaml:"dbname"`
+	SSLMode          string        `yaml:"sslmode"`
+	SSLRootCert      string        `yaml:"sslrootcert"`
+	MaxOpenConns     int           `yaml:"max_open_conns" env-default:"20"`
+	MaxIdleConns     int           `yaml:"max_idle_conns" env-default:"5"`
+	ConnMaxLifetime  time.Duration `yaml:"conn_max_lifetime" env-default:"30m"`
+	ConnMaxIdleTime  time.Duration `yaml:"conn_max_idle_time" env-default:"5m"`
+	StatementTimeout time.Duration `yaml:"statement_timeout" env-default:"30s"`
+	RetentionConfig  `yaml:"retention"`
+}
+type RetentionConfig struct {
+	Enabled            bool          `yaml:"enabled" env-default:"false"`
+	RawRetention       time.Duration `yaml:"raw" env-default:"168h"`
+	HourlyRetention    time.Duration `yaml:"hourly" env-default:"2160h"`
+	DailyRetention     time.Duration `yaml:"daily" env-default:"0"`
+	CompactionInterval time.Duration `yaml:"compaction_interval" env-default:"1h"`
+}
+type RamStorageConfig struct {
+	DataDir           string         `yaml:"data_dir"`
+	SnapshotInterval  time.Duration  `yaml:"snapshot_interval" env-default:"5m"`
+	SyncWrites        bool           `yaml:"sync_writes" env-default:"false"`
+	HistorySize       int            `yaml:"history_size" env-default:"100"`
+	SymbolHistorySize map[string]int `yaml:"symbol_history_size"`
+	MaxAge            time.Duration  `yaml:"max_age"`
+}
+type SQLiteConfig struct {
+	Path string `yaml:"path" env-default:"crypto_service.db"`
 }
 type HttpConfig struct {
-	JwtKey  string `yaml:"jwt_key" required:"true"`
-	Address string `yaml:"address" env-default:"localhost:8080"`
+	JwtKey          string        `yaml:"jwt_key" required:"true"`
+	Address         string        `yaml:"address" env-default:"localhost:8080"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"10s"`
+	StreamBuffer    int           `yaml:"stream_buffer" env-default:"64"`
+	StreamHeartbeat time.Duration `yaml:"stream_heartbeat" env-default:"15s"`
+}
+type AlertsConfig struct {
+	DefaultCooldown    time.Duration `yaml:"default_cooldown" env-default:"5m"`
+	StaleCheckInterval time.Duration `yaml:"stale_check_interval" env-default:"30s"`
+	MaxRulesPerUser    int           `yaml:"max_rules_per_user" env-default:"100"`
+}
+type WebhooksConfig struct {
+	Workers        int           `yaml:"workers" env-default:"4"`
+	Timeout        time.Duration `yaml:"timeout" env-default:"10s"`
+	MaxAttempts    int           `yaml:"max_attempts" env-default:"8"`
+	BackoffBase    time.Duration `yaml:"backoff_base" env-default:"5s"`
+	BackoffMax     time.Duration `yaml:"backoff_max" env-default:"1h"`
+	PollInterval   time.Duration `yaml:"poll_interval" env-default:"1s"`
+	HealthInterval time.Duration `yaml:"health_interval" env-default:"10s"`
+	Retention      time.Duration `yaml:"retention" env-default:"168h"`
+	MaxPerUser     int           `yaml:"max_per_user" env-default:"20"`
+	AllowPrivate   bool          `yaml:"allow_private" env-default:"false"`
+}
+type WatchlistsConfig struct {
+	MaxPerUser int `yaml:"max_per_user" env-default:"20"`
+	MaxSymbols int `yaml:"max_symbols" env-default:"100"`
+}
+type PriceSourceConfig struct {
+	SourceType           string   `yaml:"type" env-default:"coingecko"`
+	CoingeckoAddress     string   `yaml:"coingecko_address" env-default:"https://api.coingecko.com"`
+	BinanceAddress       string   `yaml:"binance_address" env-default:"https://api.binance.com"`
+	KrakenAddress        string   `yaml:"kraken_address" env-default:"https://api.kraken.com"`
+	MaxBatchSize         int      `yaml:"max_batch_size" env-default:"50"`
+	QuoteCurrencies      []string `yaml:"quote_currencies" env-default:"usd"`
+	ProviderClientConfig `yaml:"client"`
+	ConsensusConfig      `yaml:"consensus"`
+}
+type ConsensusConfig struct {
+	Sources      []string `yaml:"sources" env-default:"coingecko,binance,kraken"`
+	Method       string   `yaml:"method" env-default:"median"`
+	MaxDeviation float64  `yaml:"max_deviation" env-default:"0.05"`
+	MinSources   int      `yaml:"min_sources" env-default:"1"`
+}
+type ProviderClientConfig struct {
+	RequestTimeout   time.Duration `yaml:"request_timeout" env-default:"10s"`
+	MaxRetries       int           `yaml:"max_retries" env-default:"3"`
+	BackoffBase      time.Duration `yaml:"backoff_base" env-default:"500ms"`
+	BackoffMax       time.Duration `yaml:"backoff_max" env-default:"30s"`
+	BreakerThreshold int           `yaml:"breaker_threshold" env-default:"5"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown" env-default:"1m"`
 }
@@ -3,6 +3,9 @@ package configEnv
 import (
 	"github.com/zenrot/CryptoService/internal/config"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func MustLoad() *config.Config {
@@ -11,15 +14,125 @@ func MustLoad() *config.Config {
 		StorageType:    os.Getenv("STORAGE_TYPE"),
 		AuthorizerType: os.Getenv("AUTHORIZER_TYPE"),
 		HttpConfig: config.HttpConfig{
-			JwtKey:  os.Getenv("JWT_KEY"),
-			Address: os.Getenv("CRYPTO_SERVICE_ADDRESS"),
+			JwtKey:          os.Getenv("JWT_KEY"),
+			Address:         os.Getenv("CRYPTO_SERVICE_ADDRESS"),
+			ShutdownTimeout: getEnvDuration("CRYPTO_SERVICE_SHUTDOWN_TIMEOUT"),
+			StreamBuffer:    getEnvInt("CRYPTO_SERVICE_STREAM_BUFFER"),
+			StreamHeartbeat: getEnvDuration("CRYPTO_SERVICE_STREAM_HEARTBEAT"),
 		},
 		PostgresConfig: config.PostgresConfig{
-			Host:     os.Getenv("POSTGRES_HOST"),
-			Port:     os.Getenv("POSTGRES_PORT"),
-			User:     os.Getenv("POSTGRES_USER"),
-			Password: os.Getenv("POSTGRES_PASSWORD"),
-			Dbname:   os.Getenv("POSTGRES_DATABASE"),
+			DSN:              os.Getenv("POSTGRES_DSN"),
+			Host:             os.Getenv("POSTGRES_HOST"),
+			Port:             os.Getenv("POSTGRES_PORT"),
+			User:             os.Getenv("POSTGRES_USER"),
+			Password:         os.Getenv("POSTGRES_PASSWORD"),
+			Dbname:           os.Getenv("POSTGRES_DATABASE"),
+			SSLMode:          os.Getenv("POSTGRES_SSLMODE"),
+			SSLRootCert:      os.Getenv("POSTGRES_SSLROOTCERT"),
+			MaxOpenConns:     getEnvInt("POSTGRES_MAX_OPEN_CONNS"),
+			MaxIdleConns:     getEnvInt("POSTGRES_MAX_IDLE_CONNS"),
+			ConnMaxLifetime:  getEnvDuration("POSTGRES_CONN_MAX_LIFETIME"),
+			ConnMaxIdleTime:  getEnvDuration("POSTGRES_CONN_MAX_IDLE_TIME"),
+			StatementTimeout: getEnvDuration("POSTGRES_STATEMENT_TIMEOUT"),
+			RetentionConfig: config.RetentionConfig{
+				Enabled:            os.Getenv("POSTGRES_RETENTION_ENABLED") == "true",
+				RawRetention:       getEnvDuration("POSTGRES_RETENTION_RAW"),
+				HourlyRetention:    getEnvDuration("POSTGRES_RETENTION_HOURLY"),
+				DailyRetention:     getEnvDuration("POSTGRES_RETENTION_DAILY"),
+				CompactionInterval: getEnvDuration("POSTGRES_COMPACTION_INTERVAL"),
+			},
 		},
+		RamStorageConfig: config.RamStorageConfig{
+			DataDir:           os.Getenv("RAM_STORAGE_DATA_DIR"),
+			SnapshotInterval:  getEnvDuration("RAM_STORAGE_SNAPSHOT_INTERVAL"),
+			SyncWrites:        os.Getenv("RAM_STORAGE_SYNC_WRITES") == "true",
+			HistorySize:       getEnvInt("RAM_STORAGE_HISTORY_SIZE"),
+			SymbolHistorySize: getEnvIntMap("RAM_STORAGE_SYMBOL_HISTORY_SIZE"),
+			MaxAge:            getEnvDuration("RAM_STORAGE_MAX_AGE"),
+		},
+		SQLiteConfig: config.SQLiteConfig{
+			Path: os.Getenv("SQLITE_PATH"),
+		},
+		PriceSourceConfig: config.PriceSourceConfig{
+			SourceType:       os.Getenv("PRICE_SOURCE_TYPE"),
+			CoingeckoAddress: os.Getenv("COINGECKO_ADDRESS"),
+			BinanceAddress:   os.Getenv("BINANCE_ADDRESS"),
+			KrakenAddress:    os.Getenv("KRAKEN_ADDRESS"),
+			MaxBatchSize:     getEnvInt("PRICE_SOURCE_MAX_BATCH_SIZE"),
+			QuoteCurrencies:  getEnvList("PRICE_SOURCE_QUOTE_CURRENCIES"),
+			ProviderClientConfig: config.ProviderClientConfig{
+				RequestTimeout:   getEnvDuration("PRICE_SOURCE_REQUEST_TIMEOUT"),
+				MaxRetries:       getEnvInt("PRICE_SOURCE_MAX_RETRIES"),
+				BackoffBase:      getEnvDuration("PRICE_SOURCE_BACKOFF_BASE"),
+				BackoffMax:       getEnvDuration("PRICE_SOURCE_BACKOFF_MAX"),
+				BreakerThreshold: getEnvInt("PRICE_SOURCE_BREAKER_THRESHOLD"),
+				BreakerCooldown:  getEnvDuration("PRICE_SOURCE_BREAKER_COOLDOWN"),
+			},
+			ConsensusConfig: config.ConsensusConfig{
+				Sources:      getEnvList("PRICE_SOURCE_CONSENSUS_SOURCES"),
+				Method:       os.Getenv("PRICE_SOURCE_CONSENSUS_METHOD"),
+				MaxDeviation: getEnvFloat("PRICE_SOURCE_CONSENSUS_MAX_DEVIATION"),
+				MinSources:   getEnvInt("PRICE_SOURCE_CONSENSUS_MIN_SOURCES"),
+			},
+		},
+		AlertsConfig: config.AlertsConfig{
+			DefaultCooldown:    getEnvDuration("ALERTS_DEFAULT_COOLDOWN"),
+			StaleCheckInterval: getEnvDuration("ALERTS_STALE_CHECK_INTERVAL"),
+			MaxRulesPerUser:    getEnvInt("ALERTS_MAX_RULES_PER_USER"),
+		},
+		WebhooksConfig: config.WebhooksConfig{
+			Workers:        getEnvInt("WEBHOOKS_WORKERS"),
+			Timeout:        getEnvDuration("WEBHOOKS_TIMEOUT"),
+			MaxAttempts:    getEnvInt("WEBHOOKS_MAX_ATTEMPTS"),
+			BackoffBase:    getEnvDuration("WEBHOOKS_BACKOFF_BASE"),
+			BackoffMax:     getEnvDuration("WEBHOOKS_BACKOFF_MAX"),
+			PollInterval:   getEnvDuration("WEBHOOKS_POLL_INTERVAL"),
+			HealthInterval: getEnvDuration("WEBHOOKS_HEALTH_INTERVAL"),
+			Retention:      getEnvDuration("WEBHOOKS_RETENTION"),
+			MaxPerUser:     getEnvInt("WEBHOOKS_MAX_PER_USER"),
+			AllowPrivate:   os.Getenv("WEBHOOKS_ALLOW_PRIVATE") == "true",
+		},
+		WatchlistsConfig: config.WatchlistsConfig{
+			MaxPerUser: getEnvInt("WATCHLISTS_MAX_PER_USER"),
+			MaxSymbols: getEnvInt("WATCHLISTS_MAX_SYMBOLS"),
+		},
+	}
+}
+
+func getEnvInt(key string) int {
+	val, _ := strconv.Atoi(os.Getenv(key))
+	return val
+}
+
+func getEnvFloat(key string) float64 {
+	val, _ := strconv.ParseFloat(os.Getenv(key), 64)
+	return val
+}
+
+func getEnvList(key string) []string {
+	val := os.Getenv(key)
+	if val == "" {
+		return nil
 	}
+	return strings.Split(val, ",")
+}
+
+// getEnvIntMap parses "key=value,key=value".
+func getEnvIntMap(key string) map[string]int {
+	res := make(map[string]int)
+	for _, pair := range getEnvList(key) {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			res[strings.TrimSpace(k)] = n
+		}
+	}
+	return res
+}
+
+func getEnvDuration(key string) time.Duration {
+	val, _ := time.ParseDuration(os.Getenv(key))
+	return val
 }
@@ -1,23 +1,47 @@
 package http_server
 
 import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"github.com/zenrot/CryptoService/internal/api/alerts/deleteAlerts"
+	"github.com/zenrot/CryptoService/internal/api/alerts/getAlerts"
+	"github.com/zenrot/CryptoService/internal/api/alerts/postAlerts"
 	"github.com/zenrot/CryptoService/internal/api/auth/postAuth"
+	"github.com/zenrot/CryptoService/internal/api/coins/getCoins"
 	"github.com/zenrot/CryptoService/internal/api/crypto/deleteCrypto"
 	"github.com/zenrot/CryptoService/internal/api/crypto/getCrypto"
 	"github.com/zenrot/CryptoService/internal/api/crypto/postCrypto"
 	"github.com/zenrot/CryptoService/internal/api/crypto/putCrypto"
+	"github.com/zenrot/CryptoService/internal/api/crypto/streamCrypto"
 	"github.com/zenrot/CryptoService/internal/api/middleware/authMiddleware"
 	"github.com/zenrot/CryptoService/internal/api/schedule/getSchedule"
 	"github.com/zenrot/CryptoService/internal/api/schedule/postSchedule"
 	"github.com/zenrot/CryptoService/internal/api/schedule/putSchedule"
+	"github.com/zenrot/CryptoService/internal/api/storage/getStorage"
+	"github.com/zenrot/CryptoService/internal/api/watchlists/deleteWatchlists"
+	"github.com/zenrot/CryptoService/internal/api/watchlists/getWatchlists"
+	"github.com/zenrot/CryptoService/internal/api/watchlists/postWatchlists"
+	"github.com/zenrot/CryptoService/internal/api/watchlists/putWatchlists"
+	"github.com/zenrot/CryptoService/internal/api/webhooks/deleteWebhooks"
+	"github.com/zenrot/CryptoService/internal/api/webhooks/getWebhooks"
+	"github.com/zenrot/CryptoService/internal/api/webhooks/postWebhooks"
 	"github.com/zenrot/CryptoService/internal/auth"
 	"github.com/zenrot/CryptoService/internal/auth/internalAuth"
 	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/priceAlerts"
+	"github.com/zenrot/CryptoService/internal/priceSource/coingeckoSource"
+	"github.com/zenrot/CryptoService/internal/priceStream"
 	"github.com/zenrot/CryptoService/internal/priceUpdater"
 	"github.com/zenrot/CryptoService/internal/priceUpdater/priceUpdaterMultithreaded"
 	"github.com/zenrot/CryptoService/internal/storage"
 	"github.com/zenrot/CryptoService/internal/storage/ramstore"
+	"github.com/zenrot/CryptoService/internal/watchlistManager"
+	"github.com/zenrot/CryptoService/internal/webhookDispatcher"
 )
 
 type httpServer struct {
@@ -26,13 +50,14 @@ type httpServer struct {
 	store        storage.Crypto
 	auth         auth.Authorizer
 	priceUpdater priceUpdater.PriceUpdater
+	hub          *priceStream.Hub
+	alerts       *priceAlerts.Engine
+	webhooks     *webhookDispatcher.Dispatcher
+	watchlists   *watchlistManager.Manager
+	server       *http.Server
 }
 
 func NewHttpRouterNoConfig() *httpServer {
-	store, err := ramstore.NewRamStorage()
-	if err != nil {
-		return nil
-	}
 	jwtKey := "test"
 	config := &config.Config{
 		CoingeckoKey: "5dg5h35rVUQusTuKFCFwurnF",
@@ -41,50 +66,157 @@ func NewHttpRouterNoConfig() *httpServer {
 			JwtKey:  jwtKey,
 			Address: "localhost:8000",
 		},
+		PriceSourceConfig: config.PriceSourceConfig{
+			SourceType:       "coingecko",
+			CoingeckoAddress: "https://api.coingecko.com",
+		},
+	}
+	store, err := ramstore.NewRamStorage(config)
+	if err != nil {
+		return nil
+	}
+	hub := priceStream.New(config.StreamBuffer)
+	engine, err := priceAlerts.New(config, store)
+	if err != nil {
+		return nil
+	}
+	dispatcher, err := webhookDispatcher.New(config, store)
+	if err != nil {
+		return nil
+	}
+	updater := priceUpdaterMultithreaded.New(config,
+		engine.Evaluating(hub.Publishing(dispatcher.Notifying(store))), coingeckoSource.New(config))
+	dispatcher.WatchHealth(updater)
+	lists, err := watchlistManager.New(config, store, updater)
+	if err != nil {
+		return nil
 	}
 	return &httpServer{
 		httpCfg:      &config.HttpConfig,
-		router:       gin.Default(),
+		router:       newRouter(),
 		store:        store,
 		auth:         internalAuth.New(store, jwtKey),
-		priceUpdater: priceUpdaterMultithreaded.New(config, store),
+		priceUpdater: lists.Tracking(),
+		hub:          hub,
+		alerts:       engine,
+		webhooks:     dispatcher,
+		watchlists:   lists,
 	}
 }
-func New(cfg *config.Config, store storage.Crypto, updater priceUpdater.PriceUpdater, authorizer auth.Authorizer) *httpServer {
+func New(cfg *config.Config, store storage.Crypto, updater priceUpdater.PriceUpdater, authorizer auth.Authorizer,
+	hub *priceStream.Hub, engine *priceAlerts.Engine, dispatcher *webhookDispatcher.Dispatcher,
+	lists *watchlistManager.Manager) *httpServer {
 	return &httpServer{
 		httpCfg:      &cfg.HttpConfig,
-		router:       gin.Default(),
+		router:       newRouter(),
 		store:        store,
 		auth:         authorizer,
 		priceUpdater: updater,
+		hub:          hub,
+		alerts:       engine,
+		webhooks:     dispatcher,
+		watchlists:   lists,
 	}
 }
 
-func (hs *httpServer) Start() {
+func (hs *httpServer) Start(ctx context.Context) error {
 
-	hs.priceUpdater.Start()
+	if err := hs.priceUpdater.Start(ctx); err != nil {
+		return err
+	}
+	if err := hs.alerts.Start(ctx); err != nil {
+		return err
+	}
+	if err := hs.webhooks.Start(ctx); err != nil {
+		return err
+	}
+
+	streamHandlers := hs.router.Group("/crypto")
+	streamHandlers.Use(authMiddleware.TokenQueryMiddleware(), authMiddleware.AuthMiddleware(hs.auth))
+	{
+		streamHandlers.GET("/stream",
+			streamCrypto.CryptoStreamSSEHandler(hs.hub, hs.httpCfg.StreamHeartbeat))
+		streamHandlers.GET("/ws",
+			streamCrypto.CryptoStreamWSHandler(hs.hub, hs.httpCfg.StreamHeartbeat))
+	}
 
 	cryptoHandlers := hs.router.Group("/crypto")
 	cryptoHandlers.Use(authMiddleware.AuthMiddleware(hs.auth))
 	{
 		cryptoHandlers.GET("",
 			getCrypto.CryptoGetHandler(hs.store))
+		cryptoHandlers.GET("/health",
+			getCrypto.CryptoHealthGetHandler(hs.priceUpdater))
 		cryptoHandlers.GET("/:symbol",
-			getCrypto.CryptoSymbolGetHandler(hs.store))
+			getCrypto.CryptoSymbolGetHandler(hs.store, hs.priceUpdater))
 		cryptoHandlers.GET("/:symbol/history",
 			getCrypto.CryptoSymbolGetHistoryHandler(hs.store))
 		cryptoHandlers.GET("/:symbol/stats",
 			getCrypto.CryptoSymbolGetStatsHandler(hs.store))
+		cryptoHandlers.GET("/:symbol/candles",
+			getCrypto.CryptoSymbolGetCandlesHandler(hs.store))
+		cryptoHandlers.GET("/:symbol/sources",
+			getCrypto.CryptoSymbolGetSourcesHandler(hs.store, hs.priceUpdater))
+		cryptoHandlers.GET("/:symbol/schedule",
+			getSchedule.SymbolScheduleGetHandler(hs.priceUpdater))
 
 		cryptoHandlers.POST("",
 			postCrypto.CryptoPostHandler(hs.store, hs.priceUpdater))
+		cryptoHandlers.POST("/batch",
+			postCrypto.CryptoBatchPostHandler(hs.priceUpdater))
 
 		cryptoHandlers.PUT("/:symbol/refresh",
 			putCrypto.CryptoPutSymbolRefresh(hs.store, hs.priceUpdater))
+		cryptoHandlers.PUT("/:symbol/schedule",
+			putSchedule.SymbolSchedulePutHandler(hs.priceUpdater))
+		cryptoHandlers.DELETE("/batch",
+			deleteCrypto.CryptoBatchDeleteHandler(hs.store, hs.priceUpdater))
 		cryptoHandlers.DELETE("/:symbol",
 			deleteCrypto.CryptoDeleteSymbolHandler(hs.store, hs.priceUpdater))
 	}
 
+	coinsHandlers := hs.router.Group("/coins")
+	coinsHandlers.Use(authMiddleware.AuthMiddleware(hs.auth))
+	{
+		coinsHandlers.GET("/search", getCoins.CoinsSearchGetHandler(hs.priceUpdater))
+	}
+
+	storageHandlers := hs.router.Group("/storage")
+	storageHandlers.Use(authMiddleware.AuthMiddleware(hs.auth))
+	{
+		storageHandlers.GET("/memory", getStorage.StorageMemoryGetHandler(hs.store))
+	}
+
+	alertHandlers := hs.router.Group("/alerts")
+	alertHandlers.Use(authMiddleware.AuthMiddleware(hs.auth))
+	{
+		alertHandlers.GET("", getAlerts.AlertsGetHandler(hs.alerts))
+		alertHandlers.POST("", postAlerts.AlertsPostHandler(hs.alerts))
+		alertHandlers.DELETE("/:id", deleteAlerts.AlertDeleteHandler(hs.alerts))
+		alertHandlers.GET("/:id/events", getAlerts.AlertEventsGetHandler(hs.alerts))
+	}
+
+	webhookHandlers := hs.router.Group("/webhooks")
+	webhookHandlers.Use(authMiddleware.AuthMiddleware(hs.auth))
+	{
+		webhookHandlers.GET("", getWebhooks.WebhooksGetHandler(hs.webhooks))
+		webhookHandlers.POST("", postWebhooks.WebhooksPostHandler(hs.webhooks))
+		webhookHandlers.DELETE("/:id", deleteWebhooks.WebhookDeleteHandler(hs.webhooks))
+		webhookHandlers.GET("/:id/deliveries", getWebhooks.WebhookDeliveriesGetHandler(hs.webhooks))
+	}
+
+	watchlistHandlers := hs.router.Group("/watchlists")
+	watchlistHandlers.Use(authMiddleware.AuthMiddleware(hs.auth))
+	{
+		watchlistHandlers.GET("", getWatchlists.WatchlistsGetHandler(hs.watchlists))
+		watchlistHandlers.POST("", postWatchlists.WatchlistsPostHandler(hs.watchlists))
+		watchlistHandlers.GET("/:id", getWatchlists.WatchlistGetHandler(hs.watchlists, hs.store))
+		watchlistHandlers.PUT("/:id", putWatchlists.WatchlistPutHandler(hs.watchlists))
+		watchlistHandlers.DELETE("/:id", deleteWatchlists.WatchlistDeleteHandler(hs.watchlists))
+		watchlistHandlers.POST("/:id/symbols", postWatchlists.WatchlistSymbolsPostHandler(hs.watchlists))
+		watchlistHandlers.DELETE("/:id/symbols/:symbol", deleteWatchlists.WatchlistSymbolDeleteHandler(hs.watchlists))
+	}
+
 	authHandlers := hs.router.Group("/auth")
 	{
 		authHandlers.POST("login", postAuth.LoginHandler(hs.auth))
@@ -99,5 +231,29 @@ func (hs *httpServer) Start() {
 		scheduleHandlers.POST("trigger", postSchedule.SchedulePostRefreshHandler(hs.priceUpdater))
 	}
 
-	hs.router.Run(hs.httpCfg.Address)
+	ln, err := net.Listen("tcp", hs.httpCfg.Address)
+	if err != nil {
+		return err
+	}
+	hs.server = &http.Server{
+		Handler: hs.router,
+	}
+	go func() {
+		if err := hs.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Println(err)
+		}
+	}()
+	return nil
+}
+
+func (hs *httpServer) Shutdown(ctx context.Context) error {
+	var err error
+	// Streams only end when their subscriptions close, so close them first
+	// or server.Shutdown would wait for them until ctx expires.
+	hs.hub.Close()
+	if hs.server != nil {
+		err = hs.server.Shutdown(ctx)
+	}
+	return errors.Join(err, hs.priceUpdater.Shutdown(ctx), hs.alerts.Shutdown(ctx),
+		hs.webhooks.Shutdown(ctx))
 }
@@ -0,0 +1,18 @@
+package http_server
+
+import "testing"
+
+func TestRedactPath(t *testing.T) {
+	cases := map[string]string{
+		"/crypto/stream":                                "/crypto/stream",
+		"/crypto/stream?symbols=BTC":                    "/crypto/stream?symbols=BTC",
+		"/crypto/stream?access_token=secret":            "/crypto/stream?access_token=REDACTED",
+		"/crypto/stream?symbols=BTC&access_token=a.b.c": "/crypto/stream?access_token=REDACTED&symbols=BTC",
+		"/crypto/stream?access_token=%zz":               "/crypto/stream?[REDACTED]",
+	}
+	for path, want := range cases {
+		if got := redactPath(path); got != want {
+			t.Errorf("redactPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
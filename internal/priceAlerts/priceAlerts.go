@@ -0,0 +1,392 @@
+package priceAlerts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+const (
+	defaultCooldown           = 5 * time.Minute
+	defaultStaleCheckInterval = 30 * time.Second
+	defaultMaxRulesPerUser    = 100
+)
+
+var (
+	ErrInvalidRule  = errors.New("invalid alert rule")
+	ErrTooManyRules = errors.New("too many alert rules")
+)
+
+type seriesKey struct {
+	symbol   string
+	currency string
+}
+
+type sample struct {
+	price float64
+	time  time.Time
+}
+
+// series is the recent price history of one symbol and currency, long
+// enough for the widest percent_move window.
+type series struct {
+	seen    time.Time
+	samples []sample
+}
+
+// Engine evaluates alert rules against every price written through the store
+// returned by Evaluating and checks stale-feed rules periodically. A rule
+// changes state at most once per cooldown so that prices hovering around a
+// threshold do not flap.
+type Engine struct {
+	store storage.Alerts
+	cfg   config.AlertsConfig
+	now   func() time.Time
+
+	mu      sync.Mutex
+	rules   map[string]*storage.AlertRule
+	series  map[seriesKey]*series
+	started time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func New(cfg *config.Config, store storage.Alerts) (*Engine, error) {
+	e := &Engine{
+		store:  store,
+		cfg:    cfg.AlertsConfig,
+		now:    time.Now,
+		rules:  make(map[string]*storage.AlertRule),
+		series: make(map[seriesKey]*series),
+	}
+	if e.cfg.DefaultCooldown <= 0 {
+		e.cfg.DefaultCooldown = defaultCooldown
+	}
+	if e.cfg.StaleCheckInterval <= 0 {
+		e.cfg.StaleCheckInterval = defaultStaleCheckInterval
+	}
+	if e.cfg.MaxRulesPerUser <= 0 {
+		e.cfg.MaxRulesPerUser = defaultMaxRulesPerUser
+	}
+	rules, err := store.GetAlertRules("")
+	if err != nil {
+		return nil, fmt.Errorf("load alert rules: %w", err)
+	}
+	for _, rule := range rules {
+		e.rules[rule.ID] = &rule
+	}
+	e.started = e.now()
+	return e, nil
+}
+
+func (e *Engine) Start(ctx context.Context) error {
+	e.stop = make(chan struct{})
+	e.done = make(chan struct{})
+	go e.staleChecker(ctx, e.stop)
+	return nil
+}
+
+func (e *Engine) Shutdown(ctx context.Context) error {
+	if e.stop == nil {
+		return nil
+	}
+	close(e.stop)
+	e.stop = nil
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *Engine) staleChecker(ctx context.Context, stop <-chan struct{}) {
+	defer close(e.done)
+	ticker := time.NewTicker(e.cfg.StaleCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+			e.CheckStale()
+		}
+	}
+}
+
+// Create validates rule, fills in the ID, defaults and creation time, and
+// stores it.
+func (e *Engine) Create(rule storage.AlertRule) (storage.AlertRule, error) {
+	rule.Symbol = strings.ToLower(strings.TrimSpace(rule.Symbol))
+	rule.Currency = strings.ToLower(strings.TrimSpace(rule.Currency))
+	if rule.Currency == "" {
+		rule.Currency = storage.DefaultCurrency
+	}
+	if rule.Cooldown <= 0 {
+		rule.Cooldown = e.cfg.DefaultCooldown
+	}
+	if err := validate(rule); err != nil {
+		return storage.AlertRule{}, err
+	}
+	id, err := newID()
+	if err != nil {
+		return storage.AlertRule{}, err
+	}
+	rule.ID = id
+	rule.Triggered = false
+	rule.ChangedAt = time.Time{}
+	rule.CreatedAt = e.now().UTC()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	count := 0
+	for _, r := range e.rules {
+		if r.User == rule.User {
+			count++
+		}
+	}
+	if count >= e.cfg.MaxRulesPerUser {
+		return storage.AlertRule{}, fmt.Errorf("%w: limit is %d per user", ErrTooManyRules, e.cfg.MaxRulesPerUser)
+	}
+	if err := e.store.SaveAlertRule(rule); err != nil {
+		return storage.AlertRule{}, err
+	}
+	e.rules[rule.ID] = &rule
+	return rule, nil
+}
+
+func validate(rule storage.AlertRule) error {
+	if rule.Symbol == "" {
+		return fmt.Errorf("%w: symbol is required", ErrInvalidRule)
+	}
+	switch rule.Type {
+	case storage.AlertAbove, storage.AlertBelow:
+		if rule.Threshold <= 0 {
+			return fmt.Errorf("%w: threshold must be positive", ErrInvalidRule)
+		}
+	case storage.AlertPercentMove:
+		if rule.Threshold <= 0 {
+			return fmt.Errorf("%w: threshold must be positive", ErrInvalidRule)
+		}
+		if rule.Window <= 0 {
+			return fmt.Errorf("%w: window is required for %s", ErrInvalidRule, rule.Type)
+		}
+	case storage.AlertStale:
+		if rule.Window <= 0 {
+			return fmt.Errorf("%w: window is required for %s", ErrInvalidRule, rule.Type)
+		}
+	default:
+		return fmt.Errorf("%w: type must be one of %s, %s, %s, %s", ErrInvalidRule,
+			storage.AlertAbove, storage.AlertBelow, storage.AlertPercentMove, storage.AlertStale)
+	}
+	return nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (e *Engine) Rules(user string) []storage.AlertRule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	res := make([]storage.AlertRule, 0)
+	for _, rule := range e.rules {
+		if rule.User == user {
+			res = append(res, *rule)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].CreatedAt.Equal(res[j].CreatedAt) {
+			return res[i].CreatedAt.Before(res[j].CreatedAt)
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+func (e *Engine) Delete(user, id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if rule, ok := e.rules[id]; !ok || rule.User != user {
+		return storage.ErrAlertNotExists
+	}
+	if err := e.store.DeleteAlertRule(user, id); err != nil {
+		return err
+	}
+	delete(e.rules, id)
+	return nil
+}
+
+// Events returns the newest state changes of a rule owned by user.
+func (e *Engine) Events(user, id string, limit int) ([]storage.AlertEvent, error) {
+	e.mu.Lock()
+	rule, ok := e.rules[id]
+	owned := ok && rule.User == user
+	e.mu.Unlock()
+	if !owned {
+		return nil, storage.ErrAlertNotExists
+	}
+	return e.store.GetAlertEvents(id, limit)
+}
+
+// Evaluate records val and updates the state of every rule on its symbol and
+// currency.
+func (e *Engine) Evaluate(val storage.CryptoVal) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	key := seriesKey{symbol: val.Symbol, currency: val.Currency}
+	s := e.record(key, val)
+	now := e.now()
+	for _, rule := range e.rules {
+		if rule.Symbol != val.Symbol || rule.Currency != val.Currency {
+			continue
+		}
+		switch rule.Type {
+		case storage.AlertAbove:
+			e.transition(rule, val.Price > rule.Threshold, val.Price,
+				fmt.Sprintf("%s price %g %s, alert above %g", val.Symbol, val.Price, val.Currency, rule.Threshold), now)
+		case storage.AlertBelow:
+			e.transition(rule, val.Price < rule.Threshold, val.Price,
+				fmt.Sprintf("%s price %g %s, alert below %g", val.Symbol, val.Price, val.Currency, rule.Threshold), now)
+		case storage.AlertPercentMove:
+			change := s.change(val, rule.Window)
+			e.transition(rule, math.Abs(change) >= rule.Threshold, val.Price,
+				fmt.Sprintf("%s moved %+.2f%% within %s", val.Symbol, change, rule.Window), now)
+		case storage.AlertStale:
+			e.transition(rule, false, val.Price,
+				fmt.Sprintf("%s price received", val.Symbol), now)
+		}
+	}
+}
+
+// CheckStale triggers stale rules whose feed has been silent for longer than
+// their window. Feeds are considered fresh when the engine or rule starts.
+func (e *Engine) CheckStale() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	for _, rule := range e.rules {
+		if rule.Type != storage.AlertStale {
+			continue
+		}
+		seen := e.started
+		if rule.CreatedAt.After(seen) {
+			seen = rule.CreatedAt
+		}
+		price := 0.0
+		if s, ok := e.series[seriesKey{symbol: rule.Symbol, currency: rule.Currency}]; ok {
+			if s.seen.After(seen) {
+				seen = s.seen
+			}
+			price = s.samples[len(s.samples)-1].price
+		}
+		if silent := now.Sub(seen); silent > rule.Window {
+			e.transition(rule, true, price,
+				fmt.Sprintf("no %s price for %s", rule.Symbol, silent.Truncate(time.Second)), now)
+		}
+	}
+}
+
+// record appends val to its series and drops samples no percent_move rule
+// needs any more.
+func (e *Engine) record(key seriesKey, val storage.CryptoVal) *series {
+	s, ok := e.series[key]
+	if !ok {
+		s = &series{}
+		e.series[key] = s
+	}
+	s.seen = e.now()
+	s.samples = append(s.samples, sample{price: val.Price, time: val.Time})
+
+	var window time.Duration
+	for _, rule := range e.rules {
+		if rule.Type == storage.AlertPercentMove && rule.Symbol == key.symbol &&
+			rule.Currency == key.currency && rule.Window > window {
+			window = rule.Window
+		}
+	}
+	latest := s.samples[len(s.samples)-1].time
+	drop := 0
+	for drop < len(s.samples)-1 && latest.Sub(s.samples[drop].time) > window {
+		drop++
+	}
+	s.samples = append(s.samples[:0], s.samples[drop:]...)
+	return s
+}
+
+// change is the percent change of val against the oldest sample inside
+// window.
+func (s *series) change(val storage.CryptoVal, window time.Duration) float64 {
+	for _, smp := range s.samples {
+		if val.Time.Sub(smp.time) <= window {
+			if smp.price == 0 {
+				return 0
+			}
+			return (val.Price - smp.price) / smp.price * 100
+		}
+	}
+	return 0
+}
+
+// transition moves rule to the triggered state given by cond unless it is
+// already there or still cooling down. Callers hold e.mu.
+func (e *Engine) transition(rule *storage.AlertRule, cond bool, price float64, message string, now time.Time) {
+	if cond == rule.Triggered {
+		return
+	}
+	if !rule.ChangedAt.IsZero() && now.Sub(rule.ChangedAt) < rule.Cooldown {
+		return
+	}
+	updated := *rule
+	updated.Triggered = cond
+	updated.ChangedAt = now.UTC()
+	if err := e.store.SaveAlertRule(updated); err != nil {
+		log.Printf("alert %s: %v", rule.ID, err)
+		return
+	}
+	*rule = updated
+
+	event := storage.AlertEvent{RuleID: rule.ID, State: storage.AlertCleared, Price: price, Message: message, Time: updated.ChangedAt}
+	if cond {
+		event.State = storage.AlertTriggered
+	}
+	if err := e.store.AddAlertEvent(event); err != nil {
+		log.Printf("alert %s: %v", rule.ID, err)
+	}
+}
+
+type evaluatingStore struct {
+	storage.CryptoTracking
+	engine *Engine
+}
+
+// Evaluating returns store with AddCrypto also evaluating every stored price.
+func (e *Engine) Evaluating(store storage.CryptoTracking) storage.CryptoTracking {
+	return &evaluatingStore{CryptoTracking: store, engine: e}
+}
+
+func (es *evaluatingStore) AddCrypto(symbol, name, currency string, price float64, t time.Time) error {
+	if err := es.CryptoTracking.AddCrypto(symbol, name, currency, price, t); err != nil {
+		return err
+	}
+	es.engine.Evaluate(storage.NewCryptoVal(symbol, name, currency, price, t))
+	return nil
+}
@@ -0,0 +1,190 @@
+package priceAlerts
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/storage"
+	"github.com/zenrot/CryptoService/internal/storage/ramstore"
+)
+
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func newTestEngine(t *testing.T, cfg config.AlertsConfig) (*Engine, storage.CryptoTracking, *clock) {
+	t.Helper()
+	store, err := ramstore.NewRamStorage(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	e, err := New(&config.Config{AlertsConfig: cfg}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &clock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	e.now = c.now
+	e.started = c.t
+	return e, e.Evaluating(store), c
+}
+
+func states(t *testing.T, e *Engine, rule storage.AlertRule) []string {
+	t.Helper()
+	events, err := e.Events(rule.User, rule.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := make([]string, len(events))
+	for i, event := range events {
+		res[len(events)-1-i] = event.State
+	}
+	return res
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestThresholdCooldown(t *testing.T) {
+	e, store, c := newTestEngine(t, config.AlertsConfig{})
+	rule, err := e.Create(storage.AlertRule{User: "alice", Symbol: "BTC", Type: storage.AlertAbove, Threshold: 100, Cooldown: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.Symbol != "btc" || rule.Currency != storage.DefaultCurrency {
+		t.Fatalf("unexpected defaults %+v", rule)
+	}
+
+	add := func(price float64) {
+		c.t = c.t.Add(10 * time.Second)
+		if err := store.AddCrypto("btc", "Bitcoin", "usd", price, c.t); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add(90)
+	add(110)
+	add(90)
+	add(110)
+	if got := states(t, e, rule); !equal(got, []string{storage.AlertTriggered}) {
+		t.Fatalf("flapping within cooldown: %v", got)
+	}
+	c.t = c.t.Add(time.Minute)
+	add(90)
+	if got := states(t, e, rule); !equal(got, []string{storage.AlertTriggered, storage.AlertCleared}) {
+		t.Fatalf("expected clear after cooldown: %v", got)
+	}
+	if rules := e.Rules("alice"); rules[0].Triggered || !rules[0].ChangedAt.Equal(c.t) {
+		t.Fatalf("state not updated: %+v", rules[0])
+	}
+	add(200)
+	if err := store.AddCrypto("btc", "Bitcoin", "eur", 200, c.t); err != nil {
+		t.Fatal(err)
+	}
+	if got := states(t, e, rule); len(got) != 2 {
+		t.Fatalf("changed state within cooldown or for another currency: %v", got)
+	}
+}
+
+func TestPercentMove(t *testing.T) {
+	e, store, c := newTestEngine(t, config.AlertsConfig{})
+	rule, err := e.Create(storage.AlertRule{User: "alice", Symbol: "eth", Type: storage.AlertPercentMove, Threshold: 10, Window: 5 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, price := range []float64{100, 104, 108} {
+		if err := store.AddCrypto("eth", "Ethereum", "usd", price, c.t.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := states(t, e, rule); len(got) != 0 {
+		t.Fatalf("triggered below threshold: %v", got)
+	}
+	// 100 is outside the window by now, 104 -> 93 is a -10.6% move.
+	if err := store.AddCrypto("eth", "Ethereum", "usd", 93, c.t.Add(6*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	events, err := e.Events("alice", rule.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].State != storage.AlertTriggered || events[0].Price != 93 {
+		t.Fatalf("unexpected events %+v", events)
+	}
+}
+
+func TestStale(t *testing.T) {
+	e, store, c := newTestEngine(t, config.AlertsConfig{DefaultCooldown: time.Second})
+	rule, err := e.Create(storage.AlertRule{User: "alice", Symbol: "btc", Type: storage.AlertStale, Window: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.t = c.t.Add(30 * time.Second)
+	e.CheckStale()
+	if got := states(t, e, rule); len(got) != 0 {
+		t.Fatalf("triggered before window: %v", got)
+	}
+	c.t = c.t.Add(time.Minute)
+	e.CheckStale()
+	if err := store.AddCrypto("btc", "Bitcoin", "usd", 1, c.t); err != nil {
+		t.Fatal(err)
+	}
+	c.t = c.t.Add(30 * time.Second)
+	e.CheckStale()
+	if got := states(t, e, rule); !equal(got, []string{storage.AlertTriggered}) {
+		t.Fatalf("expected trigger only, cooldown blocks the clear: %v", got)
+	}
+	c.t = c.t.Add(10 * time.Second)
+	if err := store.AddCrypto("btc", "Bitcoin", "usd", 1, c.t); err != nil {
+		t.Fatal(err)
+	}
+	if got := states(t, e, rule); !equal(got, []string{storage.AlertTriggered, storage.AlertCleared}) {
+		t.Fatalf("expected clear on fresh price: %v", got)
+	}
+}
+
+func TestRulesPerUser(t *testing.T) {
+	e, _, _ := newTestEngine(t, config.AlertsConfig{MaxRulesPerUser: 1})
+	if _, err := e.Create(storage.AlertRule{User: "alice", Symbol: "btc", Type: "sideways"}); !errors.Is(err, ErrInvalidRule) {
+		t.Fatalf("got %v, want ErrInvalidRule", err)
+	}
+	if _, err := e.Create(storage.AlertRule{User: "alice", Symbol: "btc", Type: storage.AlertStale}); !errors.Is(err, ErrInvalidRule) {
+		t.Fatalf("stale without window: got %v, want ErrInvalidRule", err)
+	}
+	rule, err := e.Create(storage.AlertRule{User: "alice", Symbol: "btc", Type: storage.AlertBelow, Threshold: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Create(storage.AlertRule{User: "alice", Symbol: "eth", Type: storage.AlertBelow, Threshold: 1}); !errors.Is(err, ErrTooManyRules) {
+		t.Fatalf("got %v, want ErrTooManyRules", err)
+	}
+	if _, err := e.Create(storage.AlertRule{User: "bob", Symbol: "eth", Type: storage.AlertBelow, Threshold: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Events("bob", rule.ID, 0); !errors.Is(err, storage.ErrAlertNotExists) {
+		t.Fatalf("foreign events: got %v, want ErrAlertNotExists", err)
+	}
+	if err := e.Delete("bob", rule.ID); !errors.Is(err, storage.ErrAlertNotExists) {
+		t.Fatalf("foreign delete: got %v, want ErrAlertNotExists", err)
+	}
+	if err := e.Delete("alice", rule.ID); err != nil {
+		t.Fatal(err)
+	}
+	if rules := e.Rules("alice"); len(rules) != 0 {
+		t.Fatalf("rule not deleted: %+v", rules)
+	}
+}
@@ -0,0 +1,98 @@
+package binanceSource
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/priceSource"
+	"github.com/zenrot/CryptoService/internal/priceSource/providerClient"
+)
+
+type jsonGetter interface {
+	GetJSON(ctx context.Context, url string, dst any) error
+}
+
+const defaultAddress = "https://api.binance.com"
+
+type binanceSource struct {
+	addr   string
+	client jsonGetter
+}
+
+var quoteAssets = map[string]string{
+	"usd": "USDT",
+}
+
+func New(cfg *config.Config) *binanceSource {
+	addr := cfg.BinanceAddress
+	if addr == "" {
+		addr = defaultAddress
+	}
+	return &binanceSource{
+		addr:   addr,
+		client: providerClient.New("binance", cfg.ProviderClientConfig),
+	}
+}
+
+func (bs *binanceSource) SearchCoins(ctx context.Context, symbol string) ([]priceSource.CoinInfo, error) {
+	return nil, priceSource.ErrNotSupported
+}
+
+func (bs *binanceSource) GetCoin(ctx context.Context, id string) (priceSource.CoinInfo, error) {
+	return priceSource.CoinInfo{}, priceSource.ErrNotSupported
+}
+
+func (bs *binanceSource) GetPrices(ctx context.Context, coins []priceSource.CoinInfo, currencies []string) (map[string]map[string]float64, error) {
+	quotes, err := bs.GetQuotes(ctx, coins, currencies)
+	if err != nil {
+		return nil, err
+	}
+	return priceSource.QuotePrices(quotes), nil
+}
+
+func (bs *binanceSource) GetQuotes(ctx context.Context, coins []priceSource.CoinInfo, currencies []string) (map[string]map[string]priceSource.Quote, error) {
+	type ticker struct {
+		Symbol      string `json:"symbol"`
+		LastPrice   string `json:"lastPrice"`
+		QuoteVolume string `json:"quoteVolume"`
+	}
+
+	var tickers []ticker
+	if err := bs.client.GetJSON(ctx, bs.addr+"/api/v3/ticker/24hr?type=MINI", &tickers); err != nil {
+		return nil, err
+	}
+	byPair := make(map[string]ticker, len(tickers))
+	for _, t := range tickers {
+		byPair[t.Symbol] = t
+	}
+
+	res := make(map[string]map[string]priceSource.Quote)
+	for _, coin := range coins {
+		for _, currency := range currencies {
+			t, ok := byPair[pair(coin.Symbol, currency)]
+			if !ok {
+				continue
+			}
+			price, err := strconv.ParseFloat(t.LastPrice, 64)
+			if err != nil || price <= 0 {
+				continue
+			}
+			volume, _ := strconv.ParseFloat(t.QuoteVolume, 64)
+			if res[coin.ID] == nil {
+				res[coin.ID] = make(map[string]priceSource.Quote)
+			}
+			res[coin.ID][currency] = priceSource.Quote{Price: price, Volume: volume}
+		}
+	}
+	return res, nil
+}
+
+func pair(symbol, currency string) string {
+	quote, ok := quoteAssets[currency]
+	if !ok {
+		quote = strings.ToUpper(currency)
+	}
+	return strings.ToUpper(symbol) + quote
+}
@@ -0,0 +1,58 @@
+package binanceSource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/priceSource"
+)
+
+func TestGetQuotesFixture(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/ticker/24hr" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, "testdata/ticker_24hr_mini.json")
+	}))
+	defer srv.Close()
+
+	bs := New(&config.Config{
+		PriceSourceConfig: config.PriceSourceConfig{
+			BinanceAddress:       srv.URL,
+			ProviderClientConfig: config.ProviderClientConfig{RequestTimeout: time.Second},
+		},
+	})
+	quotes, err := bs.GetQuotes(context.Background(), []priceSource.CoinInfo{
+		{ID: "bitcoin", Symbol: "btc"},
+		{ID: "ethereum", Symbol: "eth"},
+		{ID: "dogecoin", Symbol: "doge"},
+		{ID: "solana", Symbol: "sol"},
+	}, []string{"usd", "eur", "btc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if q := quotes["bitcoin"]["usd"]; q.Price != 50010.5 || q.Volume != 1051706052.15 {
+		t.Errorf("bitcoin usd = %+v", q)
+	}
+	if q := quotes["bitcoin"]["eur"]; q.Price != 46020 {
+		t.Errorf("bitcoin eur = %+v", q)
+	}
+	if q := quotes["ethereum"]["btc"]; q.Price != 0.06001 {
+		t.Errorf("ethereum btc = %+v", q)
+	}
+	if _, ok := quotes["ethereum"]["eur"]; ok {
+		t.Error("unexpected ethereum eur quote")
+	}
+	if _, ok := quotes["dogecoin"]; ok {
+		t.Error("zero-priced ticker should be skipped")
+	}
+	if _, ok := quotes["solana"]; ok {
+		t.Error("unexpected solana quote")
+	}
+}
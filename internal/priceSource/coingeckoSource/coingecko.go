@@ -0,0 +1,79 @@
+package coingeckoSource
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/priceSource"
+	"github.com/zenrot/CryptoService/internal/priceSource/providerClient"
+)
+
+type jsonGetter interface {
+	GetJSON(ctx context.Context, url string, dst any) error
+}
+
+// defaultAddress matches the config file default; the env loader leaves the
+// address empty when COINGECKO_ADDRESS is unset.
+const defaultAddress = "https://api.coingecko.com"
+
+type coingeckoSource struct {
+	addr   string
+	apiKey string
+	client jsonGetter
+}
+
+func New(cfg *config.Config) *coingeckoSource {
+	addr := cfg.CoingeckoAddress
+	if addr == "" {
+		addr = defaultAddress
+	}
+	return &coingeckoSource{
+		addr:   addr,
+		apiKey: cfg.CoingeckoKey,
+		client: providerClient.New("coingecko", cfg.ProviderClientConfig),
+	}
+}
+
+func (cs *coingeckoSource) SearchCoins(ctx context.Context, symbol string) ([]priceSource.CoinInfo, error) {
+	var pathInfo = fmt.Sprintf("/api/v3/search?query=%s", url.QueryEscape(strings.ToLower(symbol)))
+
+	type searchResponse struct {
+		Coins []priceSource.CoinInfo `json:"coins"`
+	}
+
+	var searchRes searchResponse
+	if err := cs.client.GetJSON(ctx, cs.addr+pathInfo, &searchRes); err != nil {
+		return nil, err
+	}
+	return searchRes.Coins, nil
+}
+
+func (cs *coingeckoSource) GetCoin(ctx context.Context, id string) (priceSource.CoinInfo, error) {
+	var pathCoin = fmt.Sprintf("/api/v3/coins/%s?localization=false&tickers=false&market_data=false"+
+		"&community_data=false&developer_data=false&x_cg_demo_api_key=%s",
+		url.PathEscape(id), cs.apiKey)
+
+	var coin priceSource.CoinInfo
+	if err := cs.client.GetJSON(ctx, cs.addr+pathCoin, &coin); err != nil {
+		return priceSource.CoinInfo{}, err
+	}
+	return coin, nil
+}
+
+func (cs *coingeckoSource) GetPrices(ctx context.Context, coins []priceSource.CoinInfo, currencies []string) (map[string]map[string]float64, error) {
+	ids := make([]string, len(coins))
+	for i, coin := range coins {
+		ids[i] = coin.ID
+	}
+	var pathPrice = fmt.Sprintf("/api/v3/simple/price?ids=%s&vs_currencies=%s&x_cg_demo_api_key=%s",
+		url.QueryEscape(strings.Join(ids, ",")), url.QueryEscape(strings.Join(currencies, ",")), cs.apiKey)
+
+	var prices map[string]map[string]float64
+	if err := cs.client.GetJSON(ctx, cs.addr+pathPrice, &prices); err != nil {
+		return nil, err
+	}
+	return prices, nil
+}
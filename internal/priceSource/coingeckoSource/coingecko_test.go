@@ -0,0 +1,72 @@
+package coingeckoSource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/priceSource"
+)
+
+func TestGetPricesRateLimited(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"status":{"error_code":429,"error_message":"rate limited"}}`))
+	}))
+	defer srv.Close()
+
+	cs := New(&config.Config{
+		PriceSourceConfig: config.PriceSourceConfig{
+			CoingeckoAddress: srv.URL,
+			ProviderClientConfig: config.ProviderClientConfig{
+				RequestTimeout: time.Second,
+				MaxRetries:     1,
+				BackoffBase:    time.Millisecond,
+				BackoffMax:     time.Millisecond,
+			},
+		},
+	})
+	prices, err := cs.GetPrices(context.Background(), []priceSource.CoinInfo{{ID: "bitcoin", Symbol: "btc"}}, []string{"usd"})
+	if err == nil {
+		t.Fatalf("expected error, got prices %v", prices)
+	}
+}
+
+func TestGetPricesBatch(t *testing.T) {
+	var gotIDs, gotCurrencies string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotIDs = r.URL.Query().Get("ids")
+		gotCurrencies = r.URL.Query().Get("vs_currencies")
+		w.Write([]byte(`{"bitcoin":{"usd":50000,"eur":46000},"ethereum":{"usd":3000,"eur":2700}}`))
+	}))
+	defer srv.Close()
+
+	cs := New(&config.Config{
+		PriceSourceConfig: config.PriceSourceConfig{
+			CoingeckoAddress:     srv.URL,
+			ProviderClientConfig: config.ProviderClientConfig{RequestTimeout: time.Second},
+		},
+	})
+	prices, err := cs.GetPrices(context.Background(), []priceSource.CoinInfo{
+		{ID: "bitcoin", Symbol: "btc"},
+		{ID: "ethereum", Symbol: "eth"},
+	}, []string{"usd", "eur"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotIDs != "bitcoin,ethereum" || gotCurrencies != "usd,eur" {
+		t.Errorf("query ids=%q vs_currencies=%q", gotIDs, gotCurrencies)
+	}
+	if prices["ethereum"]["eur"] != 2700 {
+		t.Errorf("ethereum eur = %v, want 2700", prices["ethereum"]["eur"])
+	}
+}
+
+func TestDefaultAddress(t *testing.T) {
+	if cs := New(&config.Config{}); cs.addr != defaultAddress {
+		t.Fatalf("got address %q, want %q", cs.addr, defaultAddress)
+	}
+}
@@ -0,0 +1,287 @@
+package consensusSource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/priceSource"
+)
+
+const (
+	MethodMedian = "median"
+	MethodVWAP   = "vwap"
+)
+
+type namedSource struct {
+	name   string
+	source priceSource.PriceSource
+}
+
+type consensusSource struct {
+	sources      []namedSource
+	method       string
+	maxDeviation float64
+	minSources   int
+	quotes       map[string][]priceSource.SourceQuote
+	canonical    map[string]string
+	mismatched   map[string]bool
+	mu           sync.RWMutex
+}
+
+func New(cfg *config.Config) *consensusSource {
+	method := cfg.Method
+	if method == "" {
+		method = MethodMedian
+	}
+	return &consensusSource{
+		method:       method,
+		maxDeviation: cfg.MaxDeviation,
+		minSources:   max(cfg.MinSources, 1),
+		quotes:       make(map[string][]priceSource.SourceQuote),
+		canonical:    make(map[string]string),
+		mismatched:   make(map[string]bool),
+	}
+}
+
+// AddSource registers an upstream. The first source added also resolves
+// symbols and coin IDs, so it should be a provider with a coin catalogue.
+// Later sources are assumed to match coins by ticker symbol, like exchanges
+// do, and are only asked for coins that are canonical for their symbol.
+func (cs *consensusSource) AddSource(name string, source priceSource.PriceSource) {
+	cs.sources = append(cs.sources, namedSource{name: name, source: source})
+}
+
+func (cs *consensusSource) SearchCoins(ctx context.Context, symbol string) ([]priceSource.CoinInfo, error) {
+	if len(cs.sources) == 0 {
+		return nil, priceSource.ErrNotSupported
+	}
+	return cs.sources[0].source.SearchCoins(ctx, symbol)
+}
+
+func (cs *consensusSource) GetCoin(ctx context.Context, id string) (priceSource.CoinInfo, error) {
+	if len(cs.sources) == 0 {
+		return priceSource.CoinInfo{}, priceSource.ErrNotSupported
+	}
+	return cs.sources[0].source.GetCoin(ctx, id)
+}
+
+func (cs *consensusSource) GetPrices(ctx context.Context, coins []priceSource.CoinInfo, currencies []string) (map[string]map[string]float64, error) {
+	type result struct {
+		quotes map[string]map[string]priceSource.Quote
+		err    error
+	}
+	var byTicker []priceSource.CoinInfo
+	if len(cs.sources) > 1 {
+		byTicker = cs.tickerCoins(ctx, coins)
+	}
+	results := make([]result, len(cs.sources))
+	var wg sync.WaitGroup
+	for i, ns := range cs.sources {
+		requested := coins
+		if i > 0 {
+			if requested = byTicker; len(requested) == 0 {
+				continue
+			}
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			quotes, err := fetchQuotes(ctx, ns.source, requested, currencies)
+			if err != nil {
+				err = fmt.Errorf("%s: %w", ns.name, err)
+			}
+			results[i] = result{quotes: quotes, err: err}
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, r := range results {
+		if r.err != nil {
+			errs = append(errs, r.err)
+		}
+	}
+	if len(errs) == len(cs.sources) {
+		return nil, errors.Join(errs...)
+	}
+
+	now := time.Now()
+	prices := make(map[string]map[string]float64)
+	collected := make(map[string][]priceSource.SourceQuote)
+	for _, coin := range coins {
+		for _, currency := range currencies {
+			quotes := make([]priceSource.SourceQuote, 0, len(cs.sources))
+			for i, r := range results {
+				q, ok := r.quotes[coin.ID][currency]
+				if r.err != nil || !ok {
+					continue
+				}
+				quotes = append(quotes, priceSource.SourceQuote{
+					Source:   cs.sources[i].name,
+					Currency: currency,
+					Price:    q.Price,
+					Volume:   q.Volume,
+					Time:     now,
+				})
+			}
+			if price, ok := cs.consensus(quotes); ok {
+				if prices[coin.ID] == nil {
+					prices[coin.ID] = make(map[string]float64)
+				}
+				prices[coin.ID][currency] = price
+			}
+			collected[coin.ID] = append(collected[coin.ID], quotes...)
+		}
+	}
+
+	cs.mu.Lock()
+	for id, quotes := range collected {
+		cs.quotes[id] = quotes
+	}
+	cs.mu.Unlock()
+	return prices, nil
+}
+
+func (cs *consensusSource) SourceQuotes(id string) []priceSource.SourceQuote {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	res := make([]priceSource.SourceQuote, len(cs.quotes[id]))
+	copy(res, cs.quotes[id])
+	return res
+}
+
+// consensus drops quotes that deviate from the median by more than
+// maxDeviation and aggregates the rest, marking dropped quotes as outliers.
+func (cs *consensusSource) consensus(quotes []priceSource.SourceQuote) (float64, bool) {
+	if len(quotes) == 0 {
+		return 0, false
+	}
+	prices := make([]float64, len(quotes))
+	for i, q := range quotes {
+		prices[i] = q.Price
+	}
+	mid := median(prices)
+
+	kept := make([]priceSource.SourceQuote, 0, len(quotes))
+	for i := range quotes {
+		if cs.maxDeviation > 0 && mid > 0 && math.Abs(quotes[i].Price-mid)/mid > cs.maxDeviation {
+			quotes[i].Outlier = true
+			continue
+		}
+		kept = append(kept, quotes[i])
+	}
+	if len(kept) < cs.minSources {
+		return 0, false
+	}
+
+	if cs.method == MethodVWAP {
+		var sum, volume float64
+		for _, q := range kept {
+			if q.Volume > 0 {
+				sum += q.Price * q.Volume
+				volume += q.Volume
+			}
+		}
+		if volume > 0 {
+			return sum / volume, true
+		}
+	}
+	prices = prices[:0]
+	for _, q := range kept {
+		prices = append(prices, q.Price)
+	}
+	return median(prices), true
+}
+
+// tickerCoins returns the coins that are the top ranked match for their
+// symbol in the primary catalogue, the same one a symbol resolves to when it
+// is tracked. A ticker quote for any other coin, such as a bridged token
+// tracked by coin_id, would be the price of a different asset.
+func (cs *consensusSource) tickerCoins(ctx context.Context, coins []priceSource.CoinInfo) []priceSource.CoinInfo {
+	res := make([]priceSource.CoinInfo, 0, len(coins))
+	for _, coin := range coins {
+		id, err := cs.canonicalID(ctx, coin.Symbol)
+		if err != nil {
+			log.Printf("consensus: resolve %s: %v", coin.Symbol, err)
+			continue
+		}
+		if id == coin.ID {
+			res = append(res, coin)
+			continue
+		}
+		cs.mu.Lock()
+		if !cs.mismatched[coin.ID] {
+			cs.mismatched[coin.ID] = true
+			log.Printf("consensus: ticker %s stands for %q, pricing %s from %s only",
+				strings.ToUpper(coin.Symbol), id, coin.ID, cs.sources[0].name)
+		}
+		cs.mu.Unlock()
+	}
+	return res
+}
+
+// canonicalID returns the ID of the coin the symbol resolves to in the
+// primary catalogue, caching the answer.
+func (cs *consensusSource) canonicalID(ctx context.Context, symbol string) (string, error) {
+	symbol = strings.ToLower(symbol)
+	cs.mu.RLock()
+	id, ok := cs.canonical[symbol]
+	cs.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+	coins, err := cs.sources[0].source.SearchCoins(ctx, symbol)
+	if err != nil {
+		return "", err
+	}
+	candidates := make([]priceSource.CoinInfo, 0, len(coins))
+	for _, coin := range coins {
+		if strings.EqualFold(coin.Symbol, symbol) {
+			candidates = append(candidates, coin)
+		}
+	}
+	if len(candidates) > 0 {
+		priceSource.SortByRank(candidates)
+		id = candidates[0].ID
+	}
+	cs.mu.Lock()
+	cs.canonical[symbol] = id
+	cs.mu.Unlock()
+	return id, nil
+}
+
+func fetchQuotes(ctx context.Context, source priceSource.PriceSource, coins []priceSource.CoinInfo, currencies []string) (map[string]map[string]priceSource.Quote, error) {
+	if qs, ok := source.(priceSource.QuoteSource); ok {
+		return qs.GetQuotes(ctx, coins, currencies)
+	}
+	prices, err := source.GetPrices(ctx, coins, currencies)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]map[string]priceSource.Quote, len(prices))
+	for id, byCurrency := range prices {
+		res[id] = make(map[string]priceSource.Quote, len(byCurrency))
+		for currency, price := range byCurrency {
+			res[id][currency] = priceSource.Quote{Price: price}
+		}
+	}
+	return res, nil
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
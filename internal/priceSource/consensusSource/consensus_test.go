@@ -0,0 +1,173 @@
+package consensusSource
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/priceSource"
+	"github.com/zenrot/CryptoService/internal/priceSource/fakeSource"
+)
+
+type stubSource struct {
+	priceSource.PriceSource
+	quotes map[string]map[string]priceSource.Quote
+	err    error
+}
+
+func (ss *stubSource) GetQuotes(ctx context.Context, coins []priceSource.CoinInfo, currencies []string) (map[string]map[string]priceSource.Quote, error) {
+	if ss.err != nil {
+		return nil, ss.err
+	}
+	res := make(map[string]map[string]priceSource.Quote)
+	for _, coin := range coins {
+		if q, ok := ss.quotes[coin.ID]; ok {
+			res[coin.ID] = q
+		}
+	}
+	return res, nil
+}
+
+func stub(btcPrice, btcVolume float64) *stubSource {
+	return &stubSource{PriceSource: fakeSource.New(), quotes: map[string]map[string]priceSource.Quote{
+		"bitcoin": {"usd": {Price: btcPrice, Volume: btcVolume}},
+	}}
+}
+
+var btc = []priceSource.CoinInfo{{ID: "bitcoin", Symbol: "btc"}}
+
+func newConsensus(method string, sources ...priceSource.PriceSource) *consensusSource {
+	cs := New(&config.Config{PriceSourceConfig: config.PriceSourceConfig{
+		ConsensusConfig: config.ConsensusConfig{Method: method, MaxDeviation: 0.05, MinSources: 1},
+	}})
+	for i, source := range sources {
+		cs.AddSource(string(rune('a'+i)), source)
+	}
+	return cs
+}
+
+func TestConsensusMedianDropsOutliers(t *testing.T) {
+	cs := newConsensus(MethodMedian, stub(50000, 0), stub(50100, 0), stub(50300, 0), stub(80000, 0))
+	prices, err := cs.GetPrices(context.Background(), btc, []string{"usd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := prices["bitcoin"]["usd"]; got != 50100 {
+		t.Errorf("consensus = %v, want 50100", got)
+	}
+	quotes := cs.SourceQuotes("bitcoin")
+	if len(quotes) != 4 {
+		t.Fatalf("source quotes = %d, want 4", len(quotes))
+	}
+	for _, q := range quotes {
+		if q.Outlier != (q.Source == "d") {
+			t.Errorf("quote %+v outlier flag is wrong", q)
+		}
+	}
+}
+
+func TestConsensusVWAP(t *testing.T) {
+	cs := newConsensus(MethodVWAP, stub(50000, 3), stub(50200, 1))
+	prices, err := cs.GetPrices(context.Background(), btc, []string{"usd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := prices["bitcoin"]["usd"]; math.Abs(got-50050) > 1e-9 {
+		t.Errorf("vwap = %v, want 50050", got)
+	}
+
+	cs = newConsensus(MethodVWAP, stub(50000, 3), stub(50200, 0), stub(50100, 1))
+	prices, err = cs.GetPrices(context.Background(), btc, []string{"usd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := prices["bitcoin"]["usd"]; math.Abs(got-50025) > 1e-9 {
+		t.Errorf("vwap over quotes with volume = %v, want 50025", got)
+	}
+
+	cs = newConsensus(MethodVWAP, stub(50000, 0), stub(50200, 0), stub(50100, 0))
+	prices, err = cs.GetPrices(context.Background(), btc, []string{"usd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := prices["bitcoin"]["usd"]; got != 50100 {
+		t.Errorf("vwap without volumes = %v, want median 50100", got)
+	}
+}
+
+func TestConsensusZeroMedian(t *testing.T) {
+	cs := newConsensus(MethodMedian, stub(0, 0), stub(0, 0), stub(1, 0))
+	prices, err := cs.GetPrices(context.Background(), btc, []string{"usd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := prices["bitcoin"]["usd"]; got != 0 {
+		t.Errorf("consensus = %v, want 0", got)
+	}
+	for _, q := range cs.SourceQuotes("bitcoin") {
+		if q.Outlier {
+			t.Errorf("quote %+v flagged against a zero median", q)
+		}
+	}
+}
+
+func TestConsensusPartialFailure(t *testing.T) {
+	failing := &stubSource{PriceSource: fakeSource.New(), err: errors.New("down")}
+	fake := fakeSource.New()
+	cs := newConsensus(MethodMedian, fake, failing)
+	prices, err := cs.GetPrices(context.Background(), btc, []string{"usd", "eur"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prices["bitcoin"]["usd"] != 50000 || prices["bitcoin"]["eur"] != 25000 {
+		t.Errorf("prices = %v", prices)
+	}
+	if coin, err := cs.GetCoin(context.Background(), "bitcoin"); err != nil || coin.Symbol != "btc" {
+		t.Errorf("GetCoin via primary = %+v, %v", coin, err)
+	}
+
+	cs = newConsensus(MethodMedian, failing, &stubSource{PriceSource: fakeSource.New(), err: errors.New("also down")})
+	if _, err := cs.GetPrices(context.Background(), btc, []string{"usd"}); err == nil {
+		t.Error("expected error when every source fails")
+	}
+}
+
+func TestConsensusMinSources(t *testing.T) {
+	cs := newConsensus(MethodMedian, stub(50000, 0), &stubSource{PriceSource: fakeSource.New()})
+	cs.minSources = 2
+	prices, err := cs.GetPrices(context.Background(), btc, []string{"usd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := prices["bitcoin"]["usd"]; ok {
+		t.Error("expected no consensus below min_sources")
+	}
+}
+
+func TestConsensusSkipsTickerQuotesOfOtherCoins(t *testing.T) {
+	fake := fakeSource.New()
+	exchange := &stubSource{quotes: map[string]map[string]priceSource.Quote{
+		"bitcoin":           {"usd": {Price: 50100}},
+		"ethereum-wormhole": {"usd": {Price: 3100}},
+	}}
+	cs := newConsensus(MethodMedian, fake, exchange)
+	coins := []priceSource.CoinInfo{
+		{ID: "bitcoin", Symbol: "btc"},
+		{ID: "ethereum-wormhole", Symbol: "eth"},
+	}
+	prices, err := cs.GetPrices(context.Background(), coins, []string{"usd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := prices["bitcoin"]["usd"]; got != 50050 {
+		t.Errorf("btc consensus = %v, want 50050", got)
+	}
+	if got := prices["ethereum-wormhole"]["usd"]; got != 3000 {
+		t.Errorf("wormhole eth = %v, want the primary price 3000", got)
+	}
+	if quotes := cs.SourceQuotes("ethereum-wormhole"); len(quotes) != 1 || quotes[0].Source != "a" {
+		t.Errorf("wormhole eth quotes = %+v, want only the primary", quotes)
+	}
+}
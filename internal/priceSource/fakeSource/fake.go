@@ -0,0 +1,90 @@
+package fakeSource
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/zenrot/CryptoService/internal/priceSource"
+)
+
+type fakeSource struct {
+	coins  []priceSource.CoinInfo
+	prices map[string]float64
+	mu     sync.RWMutex
+}
+
+var rates = map[string]float64{
+	"usd": 1,
+	"eur": 0.5,
+	"gbp": 0.25,
+	"btc": 0.00002,
+}
+
+func New() *fakeSource {
+	fs := &fakeSource{
+		prices: make(map[string]float64),
+	}
+	fs.AddCoin(priceSource.CoinInfo{ID: "bitcoin", Symbol: "btc", Name: "Bitcoin", MarketCapRank: 1}, 50000)
+	fs.AddCoin(priceSource.CoinInfo{ID: "ethereum", Symbol: "eth", Name: "Ethereum", MarketCapRank: 2}, 3000)
+	fs.AddCoin(priceSource.CoinInfo{ID: "solana", Symbol: "sol", Name: "Solana", MarketCapRank: 5}, 150)
+	fs.AddCoin(priceSource.CoinInfo{ID: "dogecoin", Symbol: "doge", Name: "Dogecoin", MarketCapRank: 8}, 0.1)
+	fs.AddCoin(priceSource.CoinInfo{ID: "ethereum-wormhole", Symbol: "eth", Name: "Ethereum (Wormhole)"}, 3000)
+	return fs
+}
+
+func (fs *fakeSource) AddCoin(coin priceSource.CoinInfo, price float64) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.coins = append(fs.coins, coin)
+	fs.prices[coin.ID] = price
+}
+
+func (fs *fakeSource) SetPrice(id string, price float64) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.prices[id] = price
+}
+
+func (fs *fakeSource) SearchCoins(ctx context.Context, symbol string) ([]priceSource.CoinInfo, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	res := make([]priceSource.CoinInfo, 0)
+	for _, coin := range fs.coins {
+		if strings.EqualFold(coin.Symbol, symbol) {
+			res = append(res, coin)
+		}
+	}
+	return res, nil
+}
+
+func (fs *fakeSource) GetCoin(ctx context.Context, id string) (priceSource.CoinInfo, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	for _, coin := range fs.coins {
+		if coin.ID == id {
+			return coin, nil
+		}
+	}
+	return priceSource.CoinInfo{}, fmt.Errorf("%w: %s", priceSource.ErrUnknownCoin, id)
+}
+
+func (fs *fakeSource) GetPrices(ctx context.Context, coins []priceSource.CoinInfo, currencies []string) (map[string]map[string]float64, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	res := make(map[string]map[string]float64, len(coins))
+	for _, coin := range coins {
+		price, ok := fs.prices[coin.ID]
+		if !ok {
+			continue
+		}
+		res[coin.ID] = make(map[string]float64, len(currencies))
+		for _, currency := range currencies {
+			if rate, ok := rates[currency]; ok {
+				res[coin.ID][currency] = price * rate
+			}
+		}
+	}
+	return res, nil
+}
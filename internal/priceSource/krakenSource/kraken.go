@@ -0,0 +1,170 @@
+package krakenSource
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/priceSource"
+	"github.com/zenrot/CryptoService/internal/priceSource/providerClient"
+)
+
+type jsonGetter interface {
+	GetJSON(ctx context.Context, url string, dst any) error
+}
+
+const defaultAddress = "https://api.kraken.com"
+
+type krakenSource struct {
+	addr   string
+	client jsonGetter
+	pairs  map[string]string
+	mu     sync.Mutex
+}
+
+var assetAliases = map[string]string{
+	"btc":  "XBT",
+	"doge": "XDG",
+}
+
+func New(cfg *config.Config) *krakenSource {
+	addr := cfg.KrakenAddress
+	if addr == "" {
+		addr = defaultAddress
+	}
+	return &krakenSource{
+		addr:   addr,
+		client: providerClient.New("kraken", cfg.ProviderClientConfig),
+	}
+}
+
+func (ks *krakenSource) SearchCoins(ctx context.Context, symbol string) ([]priceSource.CoinInfo, error) {
+	return nil, priceSource.ErrNotSupported
+}
+
+func (ks *krakenSource) GetCoin(ctx context.Context, id string) (priceSource.CoinInfo, error) {
+	return priceSource.CoinInfo{}, priceSource.ErrNotSupported
+}
+
+func (ks *krakenSource) GetPrices(ctx context.Context, coins []priceSource.CoinInfo, currencies []string) (map[string]map[string]float64, error) {
+	quotes, err := ks.GetQuotes(ctx, coins, currencies)
+	if err != nil {
+		return nil, err
+	}
+	return priceSource.QuotePrices(quotes), nil
+}
+
+func (ks *krakenSource) GetQuotes(ctx context.Context, coins []priceSource.CoinInfo, currencies []string) (map[string]map[string]priceSource.Quote, error) {
+	pairs, err := ks.assetPairs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type wanted struct {
+		id       string
+		currency string
+	}
+	requested := make(map[string][]wanted)
+	for _, coin := range coins {
+		for _, currency := range currencies {
+			key, ok := pairs[wsname(coin.Symbol, currency)]
+			if !ok {
+				continue
+			}
+			requested[key] = append(requested[key], wanted{id: coin.ID, currency: currency})
+		}
+	}
+	res := make(map[string]map[string]priceSource.Quote)
+	if len(requested) == 0 {
+		return res, nil
+	}
+
+	keys := make([]string, 0, len(requested))
+	for key := range requested {
+		keys = append(keys, key)
+	}
+	type ticker struct {
+		Last   []string `json:"c"`
+		Volume []string `json:"v"`
+	}
+	type tickerResponse struct {
+		Error  []string          `json:"error"`
+		Result map[string]ticker `json:"result"`
+	}
+	var tickers tickerResponse
+	pathTicker := "/0/public/Ticker?pair=" + url.QueryEscape(strings.Join(keys, ","))
+	if err := ks.client.GetJSON(ctx, ks.addr+pathTicker, &tickers); err != nil {
+		return nil, err
+	}
+	if len(tickers.Error) > 0 {
+		return nil, fmt.Errorf("kraken: %s", strings.Join(tickers.Error, "; "))
+	}
+
+	for key, t := range tickers.Result {
+		if len(t.Last) == 0 {
+			continue
+		}
+		price, err := strconv.ParseFloat(t.Last[0], 64)
+		if err != nil || price <= 0 {
+			continue
+		}
+		var volume float64
+		if len(t.Volume) > 1 {
+			base, _ := strconv.ParseFloat(t.Volume[1], 64)
+			volume = base * price
+		}
+		for _, w := range requested[key] {
+			if res[w.id] == nil {
+				res[w.id] = make(map[string]priceSource.Quote)
+			}
+			res[w.id][w.currency] = priceSource.Quote{Price: price, Volume: volume}
+		}
+	}
+	return res, nil
+}
+
+func (ks *krakenSource) assetPairs(ctx context.Context) (map[string]string, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if ks.pairs != nil {
+		return ks.pairs, nil
+	}
+
+	type assetPair struct {
+		WSName string `json:"wsname"`
+	}
+	type pairsResponse struct {
+		Error  []string             `json:"error"`
+		Result map[string]assetPair `json:"result"`
+	}
+	var resp pairsResponse
+	if err := ks.client.GetJSON(ctx, ks.addr+"/0/public/AssetPairs", &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Error) > 0 {
+		return nil, fmt.Errorf("kraken: %s", strings.Join(resp.Error, "; "))
+	}
+	pairs := make(map[string]string, len(resp.Result))
+	for key, pair := range resp.Result {
+		if pair.WSName != "" {
+			pairs[pair.WSName] = key
+		}
+	}
+	ks.pairs = pairs
+	return pairs, nil
+}
+
+func wsname(symbol, currency string) string {
+	return asset(symbol) + "/" + asset(currency)
+}
+
+func asset(name string) string {
+	if alias, ok := assetAliases[strings.ToLower(name)]; ok {
+		return alias
+	}
+	return strings.ToUpper(name)
+}
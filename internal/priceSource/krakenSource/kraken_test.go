@@ -0,0 +1,95 @@
+package krakenSource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/priceSource"
+)
+
+func newFixtureServer(gotPairs *string, pairsCalls *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/0/public/AssetPairs":
+			*pairsCalls++
+			http.ServeFile(w, r, "testdata/asset_pairs.json")
+		case "/0/public/Ticker":
+			*gotPairs = r.URL.Query().Get("pair")
+			http.ServeFile(w, r, "testdata/ticker.json")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestGetQuotesFixture(t *testing.T) {
+	var gotPairs string
+	var pairsCalls int
+	srv := newFixtureServer(&gotPairs, &pairsCalls)
+	defer srv.Close()
+
+	ks := New(&config.Config{
+		PriceSourceConfig: config.PriceSourceConfig{
+			KrakenAddress:        srv.URL,
+			ProviderClientConfig: config.ProviderClientConfig{RequestTimeout: time.Second},
+		},
+	})
+	coins := []priceSource.CoinInfo{
+		{ID: "bitcoin", Symbol: "btc"},
+		{ID: "ethereum", Symbol: "eth"},
+		{ID: "dogecoin", Symbol: "doge"},
+		{ID: "cardano", Symbol: "ada"},
+	}
+	quotes, err := ks.GetQuotes(context.Background(), coins, []string{"usd", "btc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pairs := strings.Split(gotPairs, ",")
+	sort.Strings(pairs)
+	if strings.Join(pairs, ",") != "XDGUSD,XETHXXBT,XETHZUSD,XXBTZUSD" {
+		t.Errorf("requested pairs = %q", gotPairs)
+	}
+	if q := quotes["bitcoin"]["usd"]; q.Price != 50020 || q.Volume != 2000*50020 {
+		t.Errorf("bitcoin usd = %+v", q)
+	}
+	if q := quotes["ethereum"]["btc"]; q.Price != 0.06 {
+		t.Errorf("ethereum btc = %+v", q)
+	}
+	if q := quotes["dogecoin"]["usd"]; q.Price != 0.10015 {
+		t.Errorf("dogecoin usd = %+v", q)
+	}
+	if _, ok := quotes["cardano"]; ok {
+		t.Error("unexpected cardano quote")
+	}
+
+	if _, err := ks.GetQuotes(context.Background(), coins, []string{"usd"}); err != nil {
+		t.Fatal(err)
+	}
+	if pairsCalls != 1 {
+		t.Errorf("asset pairs fetched %d times, want 1", pairsCalls)
+	}
+}
+
+func TestGetQuotesProviderError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error":["EGeneral:Temporary lockout"]}`))
+	}))
+	defer srv.Close()
+
+	ks := New(&config.Config{
+		PriceSourceConfig: config.PriceSourceConfig{
+			KrakenAddress:        srv.URL,
+			ProviderClientConfig: config.ProviderClientConfig{RequestTimeout: time.Second},
+		},
+	})
+	if _, err := ks.GetQuotes(context.Background(), []priceSource.CoinInfo{{ID: "bitcoin", Symbol: "btc"}}, []string{"usd"}); err == nil {
+		t.Error("expected provider error")
+	}
+}
@@ -0,0 +1,69 @@
+package priceSource
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+type CoinInfo struct {
+	ID            string `json:"id"`
+	Symbol        string `json:"symbol"`
+	Name          string `json:"name"`
+	MarketCapRank int    `json:"market_cap_rank"`
+}
+
+type PriceSource interface {
+	SearchCoins(ctx context.Context, symbol string) ([]CoinInfo, error)
+	GetCoin(ctx context.Context, id string) (CoinInfo, error)
+	GetPrices(ctx context.Context, coins []CoinInfo, currencies []string) (map[string]map[string]float64, error)
+}
+
+type Quote struct {
+	Price  float64
+	Volume float64
+}
+
+type QuoteSource interface {
+	GetQuotes(ctx context.Context, coins []CoinInfo, currencies []string) (map[string]map[string]Quote, error)
+}
+
+type SourceQuote struct {
+	Source   string    `json:"source"`
+	Currency string    `json:"currency"`
+	Price    float64   `json:"price"`
+	Volume   float64   `json:"volume"`
+	Outlier  bool      `json:"outlier"`
+	Time     time.Time `json:"time"`
+}
+
+type QuoteReporter interface {
+	SourceQuotes(id string) []SourceQuote
+}
+
+var (
+	ErrNotSupported = errors.New("operation is not supported by this price source")
+	ErrUnknownCoin  = errors.New("there is no coin")
+)
+
+func QuotePrices(quotes map[string]map[string]Quote) map[string]map[string]float64 {
+	res := make(map[string]map[string]float64, len(quotes))
+	for id, byCurrency := range quotes {
+		res[id] = make(map[string]float64, len(byCurrency))
+		for currency, quote := range byCurrency {
+			res[id][currency] = quote.Price
+		}
+	}
+	return res
+}
+
+func SortByRank(coins []CoinInfo) {
+	sort.SliceStable(coins, func(i, j int) bool {
+		ri, rj := coins[i].MarketCapRank, coins[j].MarketCapRank
+		if ri == 0 || rj == 0 {
+			return ri != 0
+		}
+		return ri < rj
+	})
+}
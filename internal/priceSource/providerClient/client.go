@@ -0,0 +1,221 @@
+package providerClient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+)
+
+var ErrCircuitOpen = errors.New("provider circuit breaker is open")
+
+const (
+	defaultRequestTimeout   = 10 * time.Second
+	defaultMaxRetries       = 3
+	defaultBackoffBase      = 500 * time.Millisecond
+	defaultBackoffMax       = 30 * time.Second
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = time.Minute
+)
+
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("provider responded with status %d: %s", e.StatusCode, e.Body)
+}
+
+type providerClient struct {
+	name       string
+	httpClient *http.Client
+	cfg        config.ProviderClientConfig
+	sleep      func(ctx context.Context, d time.Duration) error
+
+	mu          sync.Mutex
+	failures    int
+	openedUntil time.Time
+	halfOpen    bool
+}
+
+func New(name string, cfg config.ProviderClientConfig) *providerClient {
+	if cfg.RequestTimeout <= 0 {
+		cfg.RequestTimeout = defaultRequestTimeout
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = defaultMaxRetries
+	}
+	if cfg.BackoffBase <= 0 {
+		cfg.BackoffBase = defaultBackoffBase
+	}
+	if cfg.BackoffMax <= 0 {
+		cfg.BackoffMax = defaultBackoffMax
+	}
+	if cfg.BreakerThreshold <= 0 {
+		cfg.BreakerThreshold = defaultBreakerThreshold
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = defaultBreakerCooldown
+	}
+	return &providerClient{
+		name:       name,
+		httpClient: &http.Client{Timeout: cfg.RequestTimeout},
+		cfg:        cfg,
+		sleep:      sleepCtx,
+	}
+}
+
+func (pc *providerClient) GetJSON(ctx context.Context, url string, dst any) error {
+	if err := pc.allow(); err != nil {
+		return err
+	}
+	err := pc.getWithRetry(ctx, url, dst)
+	pc.record(ctx, err)
+	if err != nil {
+		return fmt.Errorf("%s: %w", pc.name, err)
+	}
+	return nil
+}
+
+func (pc *providerClient) getWithRetry(ctx context.Context, url string, dst any) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retryAfter time.Duration
+		retryAfter, err = pc.get(ctx, url, dst)
+		if err == nil || !retryable(err) || attempt >= pc.cfg.MaxRetries {
+			return err
+		}
+		// Retry-After is a floor on the backoff, capped at BackoffMax.
+		delay := max(pc.backoff(attempt), min(retryAfter, pc.cfg.BackoffMax))
+		if err := pc.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+func (pc *providerClient) get(ctx context.Context, url string, dst any) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := pc.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return parseRetryAfter(resp.Header.Get("Retry-After")), &StatusError{
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return 0, err
+	}
+	return 0, nil
+}
+
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	return providerFailure(err)
+}
+
+// providerFailure reports whether err means the provider itself is failing:
+// a transport error, 5xx or 429. Other 4xx and undecodable bodies are caused
+// by the request, such as an unknown coin ID.
+func providerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr)
+}
+
+func (pc *providerClient) backoff(attempt int) time.Duration {
+	d := pc.cfg.BackoffBase << attempt
+	if d <= 0 || d > pc.cfg.BackoffMax {
+		d = pc.cfg.BackoffMax
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(half+1)
+}
+
+func parseRetryAfter(val string) time.Duration {
+	if val == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(val); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(val); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+func (pc *providerClient) allow() error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.failures < pc.cfg.BreakerThreshold {
+		return nil
+	}
+	if time.Now().Before(pc.openedUntil) || pc.halfOpen {
+		return fmt.Errorf("%s: %w", pc.name, ErrCircuitOpen)
+	}
+	pc.halfOpen = true
+	return nil
+}
+
+// record updates the breaker with the outcome of a request. Only provider
+// failures count towards it, so that requests for unknown coins cannot open
+// the circuit for everyone; requests cancelled by the caller do not count
+// either way.
+func (pc *providerClient) record(ctx context.Context, err error) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.halfOpen = false
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+	if !providerFailure(err) {
+		pc.failures = 0
+		return
+	}
+	pc.failures++
+	if pc.failures >= pc.cfg.BreakerThreshold {
+		pc.openedUntil = time.Now().Add(pc.cfg.BreakerCooldown)
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,212 @@
+package providerClient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+)
+
+func testClient(cfg config.ProviderClientConfig) (*providerClient, *[]time.Duration) {
+	pc := New("test", cfg)
+	sleeps := &[]time.Duration{}
+	pc.sleep = func(ctx context.Context, d time.Duration) error {
+		*sleeps = append(*sleeps, d)
+		return nil
+	}
+	return pc, sleeps
+}
+
+func defaultTestConfig() config.ProviderClientConfig {
+	return config.ProviderClientConfig{
+		RequestTimeout:   time.Second,
+		MaxRetries:       3,
+		BackoffBase:      100 * time.Millisecond,
+		BackoffMax:       10 * time.Second,
+		BreakerThreshold: 2,
+		BreakerCooldown:  50 * time.Millisecond,
+	}
+}
+
+func TestGetJSONRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"price": 42}`))
+	}))
+	defer srv.Close()
+
+	pc, sleeps := testClient(defaultTestConfig())
+	var res struct {
+		Price float64 `json:"price"`
+	}
+	if err := pc.GetJSON(context.Background(), srv.URL, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Price != 42 {
+		t.Errorf("price = %v, want 42", res.Price)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("calls = %d, want 3", calls.Load())
+	}
+	if len(*sleeps) != 2 {
+		t.Fatalf("sleeps = %v, want 2 entries", *sleeps)
+	}
+	if d := (*sleeps)[0]; d < 50*time.Millisecond || d > 100*time.Millisecond {
+		t.Errorf("first backoff = %v, want within [50ms, 100ms]", d)
+	}
+	if d := (*sleeps)[1]; d < 100*time.Millisecond || d > 200*time.Millisecond {
+		t.Errorf("second backoff = %v, want within [100ms, 200ms]", d)
+	}
+}
+
+func TestGetJSONHonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	pc, sleeps := testClient(defaultTestConfig())
+	var res map[string]any
+	if err := pc.GetJSON(context.Background(), srv.URL, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(*sleeps) != 1 || (*sleeps)[0] != 7*time.Second {
+		t.Errorf("sleeps = %v, want [7s]", *sleeps)
+	}
+}
+
+func TestGetJSONDoesNotDecodeErrorStatus(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"price": 1}`))
+	}))
+	defer srv.Close()
+
+	pc, _ := testClient(defaultTestConfig())
+	var res map[string]any
+	err := pc.GetJSON(context.Background(), srv.URL, &res)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("err = %v, want StatusError 404", err)
+	}
+	if res != nil {
+		t.Errorf("response was decoded: %v", res)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1 (no retry on 4xx)", calls.Load())
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	cfg := defaultTestConfig()
+	cfg.MaxRetries = 1
+	pc, _ := testClient(cfg)
+	var res map[string]any
+	for i := 0; i < 2; i++ {
+		if err := pc.GetJSON(context.Background(), srv.URL, &res); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if err := pc.GetJSON(context.Background(), srv.URL, &res); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if calls.Load() != 4 {
+		t.Errorf("calls = %d, want 4 while breaker is open", calls.Load())
+	}
+
+	time.Sleep(cfg.BreakerCooldown)
+	fail.Store(false)
+	if err := pc.GetJSON(context.Background(), srv.URL, &res); err != nil {
+		t.Fatal("half-open trial err:", err)
+	}
+	if err := pc.GetJSON(context.Background(), srv.URL, &res); err != nil {
+		t.Fatal("closed breaker err:", err)
+	}
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	cfg := defaultTestConfig()
+	pc, _ := testClient(cfg)
+	var res map[string]any
+	for i := 0; i < cfg.BreakerThreshold*2; i++ {
+		var statusErr *StatusError
+		if err := pc.GetJSON(context.Background(), srv.URL, &res); !errors.As(err, &statusErr) {
+			t.Fatalf("request %d: err = %v, want StatusError 404", i, err)
+		}
+	}
+	if calls.Load() != int32(cfg.BreakerThreshold*2) {
+		t.Errorf("calls = %d, want %d: 404s must not open the breaker", calls.Load(), cfg.BreakerThreshold*2)
+	}
+}
+
+func TestDefaultRequestTimeout(t *testing.T) {
+	if pc := New("test", config.ProviderClientConfig{}); pc.httpClient.Timeout != defaultRequestTimeout {
+		t.Fatalf("timeout = %s, want %s", pc.httpClient.Timeout, defaultRequestTimeout)
+	}
+}
+
+func TestZeroConfigUsesDefaults(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	pc, sleeps := testClient(config.ProviderClientConfig{})
+	var res map[string]any
+	for i := 0; i < defaultBreakerThreshold; i++ {
+		if err := pc.GetJSON(context.Background(), srv.URL, &res); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if err := pc.GetJSON(context.Background(), srv.URL, &res); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen after %d failures", err, defaultBreakerThreshold)
+	}
+	if want := int32(defaultBreakerThreshold * (defaultMaxRetries + 1)); calls.Load() != want {
+		t.Errorf("calls = %d, want %d", calls.Load(), want)
+	}
+	for _, d := range *sleeps {
+		if d <= 0 || d > defaultBackoffMax {
+			t.Fatalf("sleeps = %v, want every delay within (0, %s]", *sleeps, defaultBackoffMax)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package priceStream
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+const defaultBufferSize = 64
+
+// Hub fans stored prices out to subscribers. Publishing never blocks: a
+// subscriber whose buffer is full loses its oldest pending price.
+type Hub struct {
+	mu         sync.RWMutex
+	subs       map[*Subscription]struct{}
+	bufferSize int
+	closed     bool
+}
+
+type Subscription struct {
+	C <-chan storage.CryptoVal
+
+	ch       chan storage.CryptoVal
+	hub      *Hub
+	currency string
+	dropped  atomic.Int64
+
+	mu      sync.RWMutex
+	all     bool
+	symbols map[string]bool
+}
+
+func New(bufferSize int) *Hub {
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	return &Hub{
+		subs:       make(map[*Subscription]struct{}),
+		bufferSize: bufferSize,
+	}
+}
+
+// Subscribe returns a subscription to prices in currency ("" for all) for
+// symbols; with no symbols it receives every symbol until Add narrows it.
+// Its channel is closed by Close or when the hub closes.
+func (h *Hub) Subscribe(symbols []string, currency string) *Subscription {
+	ch := make(chan storage.CryptoVal, h.bufferSize)
+	sub := &Subscription{
+		C:        ch,
+		ch:       ch,
+		hub:      h,
+		currency: currency,
+		all:      len(symbols) == 0,
+		symbols:  make(map[string]bool),
+	}
+	for _, symbol := range symbols {
+		sub.symbols[symbol] = true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(ch)
+		return sub
+	}
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+func (h *Hub) Publish(val storage.CryptoVal) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subs {
+		if sub.matches(val) {
+			sub.send(val)
+		}
+	}
+}
+
+// Close closes every subscription; later subscriptions are closed at once.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subs {
+		delete(h.subs, sub)
+		close(sub.ch)
+	}
+}
+
+func (h *Hub) Subscribers() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs)
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if _, ok := s.hub.subs[s]; ok {
+		delete(s.hub.subs, s)
+		close(s.ch)
+	}
+}
+
+func (s *Subscription) Add(symbols ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, symbol := range symbols {
+		if symbol != "" {
+			s.symbols[symbol] = true
+			s.all = false
+		}
+	}
+}
+
+func (s *Subscription) Remove(symbols ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, symbol := range symbols {
+		delete(s.symbols, symbol)
+	}
+}
+
+func (s *Subscription) Symbols() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	res := make([]string, 0, len(s.symbols))
+	for symbol := range s.symbols {
+		res = append(res, symbol)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Dropped is the number of prices discarded because the subscriber was slow.
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+func (s *Subscription) matches(val storage.CryptoVal) bool {
+	if s.currency != "" && s.currency != val.Currency {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.all || s.symbols[val.Symbol]
+}
+
+func (s *Subscription) send(val storage.CryptoVal) {
+	select {
+	case s.ch <- val:
+		return
+	default:
+	}
+	select {
+	case <-s.ch:
+		s.dropped.Add(1)
+	default:
+	}
+	select {
+	case s.ch <- val:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+type publishingStore struct {
+	storage.CryptoTracking
+	hub *Hub
+}
+
+// Publishing returns store with AddCrypto also publishing every stored price
+// to the hub.
+func (h *Hub) Publishing(store storage.CryptoTracking) storage.CryptoTracking {
+	return &publishingStore{CryptoTracking: store, hub: h}
+}
+
+func (ps *publishingStore) AddCrypto(symbol, name, currency string, price float64, t time.Time) error {
+	if err := ps.CryptoTracking.AddCrypto(symbol, name, currency, price, t); err != nil {
+		return err
+	}
+	ps.hub.Publish(storage.NewCryptoVal(symbol, name, currency, price, t))
+	return nil
+}
@@ -0,0 +1,107 @@
+package priceStream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/storage"
+	"github.com/zenrot/CryptoService/internal/storage/ramstore"
+)
+
+func newVal(symbol, currency string, t time.Time) storage.CryptoVal {
+	return storage.NewCryptoVal(symbol, symbol, currency, 1, t)
+}
+
+func TestFiltering(t *testing.T) {
+	hub := New(8)
+	all := hub.Subscribe(nil, "")
+	btc := hub.Subscribe([]string{"btc"}, "usd")
+	defer all.Close()
+	defer btc.Close()
+
+	now := time.Now()
+	for _, v := range []struct {
+		symbol, currency string
+	}{{"btc", "usd"}, {"eth", "usd"}, {"btc", "eur"}} {
+		hub.Publish(newVal(v.symbol, v.currency, now))
+	}
+	if len(all.C) != 3 {
+		t.Fatalf("expected 3 prices for all symbols, got %d", len(all.C))
+	}
+	if len(btc.C) != 1 {
+		t.Fatalf("expected 1 btc/usd price, got %d", len(btc.C))
+	}
+
+	btc.Add("eth")
+	btc.Remove("btc")
+	<-btc.C
+	hub.Publish(newVal("btc", "usd", now))
+	hub.Publish(newVal("eth", "usd", now))
+	if v := <-btc.C; v.Symbol != "eth" || len(btc.C) != 0 {
+		t.Fatalf("unexpected price after resubscribe: %+v", v)
+	}
+	if got := btc.Symbols(); len(got) != 1 || got[0] != "eth" {
+		t.Fatalf("unexpected symbols: %v", got)
+	}
+}
+
+func TestSlowConsumerDropsOldest(t *testing.T) {
+	hub := New(2)
+	sub := hub.Subscribe(nil, "")
+	defer sub.Close()
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		hub.Publish(newVal("btc", "usd", base.Add(time.Duration(i)*time.Minute)))
+	}
+	if sub.Dropped() != 3 {
+		t.Fatalf("expected 3 dropped, got %d", sub.Dropped())
+	}
+	if v := <-sub.C; !v.Time.Equal(base.Add(3 * time.Minute)) {
+		t.Fatalf("expected the newest prices to be kept, got %v", v.Time)
+	}
+}
+
+func TestClose(t *testing.T) {
+	hub := New(0)
+	sub := hub.Subscribe(nil, "")
+	closed := hub.Subscribe(nil, "")
+	closed.Close()
+	closed.Close()
+	if hub.Subscribers() != 1 {
+		t.Fatalf("expected 1 subscriber, got %d", hub.Subscribers())
+	}
+
+	hub.Close()
+	if _, ok := <-sub.C; ok {
+		t.Fatal("expected subscription channel to be closed")
+	}
+	sub.Close()
+	if _, ok := <-hub.Subscribe(nil, "").C; ok {
+		t.Fatal("expected subscription after hub close to be closed")
+	}
+	hub.Publish(newVal("btc", "usd", time.Now()))
+}
+
+func TestPublishingStore(t *testing.T) {
+	store, err := ramstore.NewRamStorage(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	hub := New(8)
+	sub := hub.Subscribe([]string{"btc"}, "")
+	defer sub.Close()
+
+	ps := hub.Publishing(store)
+	if err := ps.AddCrypto("btc", "Bitcoin", "usd", 42, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-sub.C; v.Price != 42 || v.Name != "Bitcoin" {
+		t.Fatalf("unexpected published price: %+v", v)
+	}
+	if _, err := store.GetLatestCrypto("usd"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,23 +1,61 @@
 package priceUpdater
 
 import (
+	"context"
+	"errors"
 	"time"
+
+	"github.com/zenrot/CryptoService/internal/priceSource"
 )
 
-type CoinInfo struct {
-	ID     string `json:"id"`
-	Symbol string `json:"symbol"`
-	Name   string `json:"name"`
+type Schedule struct {
+	Enabled     bool
+	Interval    time.Duration
+	Custom      bool
+	LastUpdated time.Time
+	NextUpdate  time.Time
+}
+
+const (
+	StatusPending = "pending"
+	StatusOK      = "ok"
+	StatusFailing = "failing"
+)
+
+type Health struct {
+	Status              string
+	LastSuccess         time.Time
+	LastError           string
+	LastErrorTime       time.Time
+	ConsecutiveFailures int
+	Stale               bool
 }
 
 type PriceUpdater interface {
-	Start()
+	Start(ctx context.Context) error
+	Shutdown(ctx context.Context) error
 	RefreshPrice(Symbol string) error
-	AddCryptoTracking(Symbol string) error
+	SearchCoins(query string) ([]priceSource.CoinInfo, error)
+	AddCryptoTracking(Symbol string) (priceSource.CoinInfo, error)
+	AddCryptoTrackingByID(ID string) (priceSource.CoinInfo, error)
 	DeleteCryptoTracking(Symbol string) error
+	DeleteCryptoTrackingByID(ID string) (priceSource.CoinInfo, error)
 	GetUpdateTime() time.Duration
 	ChangeUpdateTime(t time.Duration) error
 	StopUpdating() error
+	GetSchedule(Symbol string) (Schedule, error)
+	GetSchedules() map[string]Schedule
+	SetSchedule(Symbol string, enabled bool, interval time.Duration) error
+	GetHealth(Symbol string) (Health, error)
+	GetHealths() map[string]Health
+	GetSourceQuotes(Symbol string) ([]priceSource.SourceQuote, error)
 	GetLastUpdated() time.Time
 	RefreshAllPrices() (int, error)
 }
+
+var (
+	ErrAlreadyStarted = errors.New("price updater already started")
+	ErrStopped        = errors.New("price updater is stopped")
+	ErrAlreadyTracked = errors.New("this coin already exists")
+	ErrNotTracked     = errors.New("not being tracked")
+)
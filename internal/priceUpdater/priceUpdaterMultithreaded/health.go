@@ -0,0 +1,57 @@
+package priceUpdaterMultithreaded
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
+)
+
+func (pu *priceUpdaterInternal) GetHealth(Symbol string) (priceUpdater.Health, error) {
+	pu.mu.RLock()
+	defer pu.mu.RUnlock()
+	coin, ok := pu.coinBySymbol(Symbol)
+	if !ok {
+		return priceUpdater.Health{}, fmt.Errorf("symbol %s is %w", Symbol, priceUpdater.ErrNotTracked)
+	}
+	return coin.health(time.Now()), nil
+}
+
+func (pu *priceUpdaterInternal) GetHealths() map[string]priceUpdater.Health {
+	pu.mu.RLock()
+	defer pu.mu.RUnlock()
+	now := time.Now()
+	res := make(map[string]priceUpdater.Health, len(pu.coins))
+	for _, coin := range pu.coins {
+		res[coin.info.Symbol] = coin.health(now)
+	}
+	return res
+}
+
+func (coin *trackedCoin) health(now time.Time) priceUpdater.Health {
+	h := priceUpdater.Health{
+		Status:              priceUpdater.StatusOK,
+		LastSuccess:         coin.lastUpdated,
+		LastError:           coin.lastError,
+		LastErrorTime:       coin.lastErrorTime,
+		ConsecutiveFailures: coin.failures,
+	}
+	switch {
+	case coin.failures > 0:
+		h.Status = priceUpdater.StatusFailing
+	case coin.lastUpdated.IsZero():
+		h.Status = priceUpdater.StatusPending
+	}
+	h.Stale = coin.lastUpdated.IsZero() || now.Sub(coin.lastUpdated) > staleIntervals*coin.interval
+	return h
+}
+
+func (pu *priceUpdaterInternal) markFailed(ID string, err error, t time.Time) {
+	pu.mu.Lock()
+	defer pu.mu.Unlock()
+	if coin, ok := pu.coins[ID]; ok {
+		coin.lastError = err.Error()
+		coin.lastErrorTime = t
+		coin.failures++
+	}
+}
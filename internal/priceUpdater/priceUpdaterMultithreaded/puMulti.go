@@ -1,242 +1,444 @@
 package priceUpdaterMultithreaded
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
+	"log"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/priceSource"
 	"github.com/zenrot/CryptoService/internal/priceUpdater"
 	"github.com/zenrot/CryptoService/internal/storage"
 )
 
+type trackedCoin struct {
+	info        priceSource.CoinInfo
+	enabled     bool
+	interval    time.Duration
+	custom      bool
+	lastUpdated time.Time
+	nextUpdate  time.Time
+
+	lastError     string
+	lastErrorTime time.Time
+	failures      int
+}
+
 type priceUpdaterInternal struct {
-	apiKey     string
-	numWorkers int
-	autoUpdate time.Duration
-	store      storage.Crypto
-	coins      map[string]priceUpdater.CoinInfo
-	lastUpdate time.Time
-
-	chErrorWorkers        chan error
-	chErrorSearcherDaemon chan error
-	chUpdate              map[string]chan time.Duration
-	chCoins               chan string
-	chDelete              map[string]chan struct{}
-	chRefresh             map[string]chan struct{}
-	wg                    sync.WaitGroup
-	mu                    sync.RWMutex
-}
-
-func New(cfg *config.Config, store storage.Crypto) *priceUpdaterInternal {
+	source      priceSource.PriceSource
+	batchSize   int
+	currencies  []string
+	autoUpdate  time.Duration
+	autoEnabled bool
+	store       storage.CryptoTracking
+	coins       map[string]*trackedCoin
+	symbols     map[string]string
+	lastUpdate  time.Time
+
+	ctx            context.Context
+	cancel         context.CancelFunc
+	started        bool
+	stopped        bool
+	chErrorWorkers chan error
+	chReschedule   chan struct{}
+	chStop         chan struct{}
+	chWorkDone     chan struct{}
+	chErrorsDone   chan struct{}
+	inFlight       sync.WaitGroup
+	mu             sync.RWMutex
+}
+
+const (
+	defaultBatchSize = 50
+	idleWakeup       = time.Hour
+	staleIntervals   = 3
+)
+
+func New(cfg *config.Config, store storage.CryptoTracking, source priceSource.PriceSource) *priceUpdaterInternal {
+	batchSize := cfg.MaxBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	currencies := make([]string, 0, len(cfg.QuoteCurrencies))
+	for _, currency := range cfg.QuoteCurrencies {
+		currencies = append(currencies, strings.ToLower(strings.TrimSpace(currency)))
+	}
+	if len(currencies) == 0 {
+		currencies = []string{storage.DefaultCurrency}
+	}
 	return &priceUpdaterInternal{
-		apiKey:     cfg.CoingeckoKey,
-		autoUpdate: 3 * time.Second,
-		store:      store,
+		source:      source,
+		batchSize:   batchSize,
+		currencies:  currencies,
+		autoUpdate:  3 * time.Second,
+		autoEnabled: true,
+		store:       store,
+		ctx:         context.Background(),
+		cancel:      func() {},
 	}
 }
 
-const addr = "https://api.coingecko.com"
-
-func (pu *priceUpdaterInternal) Start() {
+func (pu *priceUpdaterInternal) Start(ctx context.Context) error {
+	pu.mu.Lock()
+	defer pu.mu.Unlock()
+	if pu.started {
+		return priceUpdater.ErrAlreadyStarted
+	}
+	pu.coins = make(map[string]*trackedCoin)
+	pu.symbols = make(map[string]string)
+	if err := pu.restore(); err != nil {
+		return err
+	}
+	pu.started = true
+	pu.ctx, pu.cancel = context.WithCancel(ctx)
 	pu.chErrorWorkers = make(chan error)
-	pu.chErrorSearcherDaemon = make(chan error)
-	pu.chDelete = make(map[string]chan struct{})
-	pu.chRefresh = make(map[string]chan struct{})
-	pu.chUpdate = make(map[string]chan time.Duration)
-	pu.chCoins = make(chan string)
-	pu.coins = make(map[string]priceUpdater.CoinInfo)
-	pu.numWorkers = 0
-	go pu.searcherDaemon()
+	pu.chReschedule = make(chan struct{}, 1)
+	pu.chStop = make(chan struct{})
+	pu.chWorkDone = make(chan struct{})
+	pu.chErrorsDone = make(chan struct{})
+	go pu.work(pu.ctx)
 	go pu.errorHandler()
+	return nil
 }
 
-func (pu *priceUpdaterInternal) RefreshPrice(Symbol string) error {
+func (pu *priceUpdaterInternal) restore() error {
+	settings, ok, err := pu.store.GetDefaultSchedule()
+	if err != nil {
+		return err
+	}
+	if ok {
+		pu.autoEnabled = settings.Enabled
+		if settings.Interval > 0 {
+			pu.autoUpdate = settings.Interval
+		}
+	}
 
-	if _, ok := pu.coins[Symbol]; !ok {
-		return fmt.Errorf("symbol %s is not being tracked", Symbol)
+	coins, err := pu.store.GetTrackedCoins()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, coin := range coins {
+		interval := coin.Interval
+		if interval <= 0 {
+			interval = pu.autoUpdate
+		}
+		pu.coins[coin.ID] = &trackedCoin{
+			info: priceSource.CoinInfo{
+				ID:     coin.ID,
+				Symbol: coin.Symbol,
+				Name:   coin.Name,
+			},
+			enabled:    coin.Enabled,
+			interval:   interval,
+			custom:     coin.Custom,
+			nextUpdate: now,
+		}
+		pu.symbols[coin.Symbol] = coin.ID
 	}
-	pu.wg.Add(1)
-	pu.chRefresh[Symbol] <- struct{}{}
-	pu.wg.Wait()
 	return nil
 }
+
+func (pu *priceUpdaterInternal) Shutdown(ctx context.Context) error {
+	pu.mu.Lock()
+	if !pu.started || pu.stopped {
+		pu.mu.Unlock()
+		return nil
+	}
+	pu.stopped = true
+	close(pu.chStop)
+	pu.cancel()
+	pu.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		<-pu.chWorkDone
+		pu.inFlight.Wait()
+		close(pu.chErrorWorkers)
+		<-pu.chErrorsDone
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (pu *priceUpdaterInternal) RefreshPrice(Symbol string) error {
+	pu.mu.RLock()
+	coin, ok := pu.coinBySymbol(Symbol)
+	pu.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("symbol %s is %w", Symbol, priceUpdater.ErrNotTracked)
+	}
+	if errs := pu.fetchPrices([]priceSource.CoinInfo{coin.info}); errs[Symbol] != nil {
+		return errs[Symbol]
+	}
+	return nil
+}
+
 func (pu *priceUpdaterInternal) RefreshAllPrices() (int, error) {
-	for _, coin := range pu.coins {
-		pu.wg.Add(1)
-		pu.chRefresh[coin.Symbol] <- struct{}{}
+	coins := pu.trackedCoins()
+	errs := pu.fetchPrices(coins)
+	return len(coins) - len(errs), nil
+}
+
+func (pu *priceUpdaterInternal) SearchCoins(query string) ([]priceSource.CoinInfo, error) {
+	pu.mu.RLock()
+	ctx := pu.ctx
+	pu.mu.RUnlock()
+	coins, err := pu.source.SearchCoins(ctx, query)
+	if err != nil {
+		return nil, err
 	}
-	pu.wg.Wait()
-	return pu.numWorkers, nil
+	priceSource.SortByRank(coins)
+	return coins, nil
 }
 
-func (pu *priceUpdaterInternal) AddCryptoTracking(Symbol string) error {
-	pu.wg.Add(1)
-	pu.chCoins <- Symbol
-	pu.wg.Wait()
-	return <-pu.chErrorSearcherDaemon
+func (pu *priceUpdaterInternal) GetSourceQuotes(Symbol string) ([]priceSource.SourceQuote, error) {
+	pu.mu.RLock()
+	coin, ok := pu.coinBySymbol(Symbol)
+	pu.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("symbol %s is %w", Symbol, priceUpdater.ErrNotTracked)
+	}
+	reporter, ok := pu.source.(priceSource.QuoteReporter)
+	if !ok {
+		return []priceSource.SourceQuote{}, nil
+	}
+	return reporter.SourceQuotes(coin.info.ID), nil
 }
 
-func (pu *priceUpdaterInternal) DeleteCryptoTracking(Symbol string) error {
-	if _, ok := pu.coins[Symbol]; !ok {
-		return fmt.Errorf("symbol %s is not being tracked", Symbol)
+func (pu *priceUpdaterInternal) AddCryptoTracking(Symbol string) (priceSource.CoinInfo, error) {
+	pu.mu.RLock()
+	_, ok := pu.coinBySymbol(Symbol)
+	ctx, stopped := pu.ctx, pu.stopped
+	pu.mu.RUnlock()
+	if stopped {
+		return priceSource.CoinInfo{}, priceUpdater.ErrStopped
+	}
+	if ok {
+		return priceSource.CoinInfo{}, fmt.Errorf("%w: %s", priceUpdater.ErrAlreadyTracked, Symbol)
+	}
+
+	coins, err := pu.source.SearchCoins(ctx, Symbol)
+	if err != nil {
+		return priceSource.CoinInfo{}, err
+	}
+	candidates := make([]priceSource.CoinInfo, 0, len(coins))
+	for _, coin := range coins {
+		if coin.Symbol == Symbol {
+			candidates = append(candidates, coin)
+		}
 	}
-	pu.chDelete[Symbol] <- struct{}{}
-	delete(pu.coins, Symbol)
+	if len(candidates) == 0 {
+		return priceSource.CoinInfo{}, fmt.Errorf("%w: %s", priceSource.ErrUnknownCoin, Symbol)
+	}
+	priceSource.SortByRank(candidates)
+
+	return candidates[0], pu.track(candidates[0])
+}
+
+func (pu *priceUpdaterInternal) AddCryptoTrackingByID(ID string) (priceSource.CoinInfo, error) {
+	pu.mu.RLock()
+	_, ok := pu.coins[ID]
+	ctx, stopped := pu.ctx, pu.stopped
+	pu.mu.RUnlock()
+	if stopped {
+		return priceSource.CoinInfo{}, priceUpdater.ErrStopped
+	}
+	if ok {
+		return priceSource.CoinInfo{}, fmt.Errorf("%w: %s", priceUpdater.ErrAlreadyTracked, ID)
+	}
+
+	coin, err := pu.source.GetCoin(ctx, ID)
+	if err != nil {
+		return priceSource.CoinInfo{}, err
+	}
+	return coin, pu.track(coin)
+}
+
+func (pu *priceUpdaterInternal) track(coin priceSource.CoinInfo) error {
+	pu.mu.Lock()
+	if _, ok := pu.coins[coin.ID]; ok {
+		pu.mu.Unlock()
+		return fmt.Errorf("%w: %s", priceUpdater.ErrAlreadyTracked, coin.ID)
+	}
+	if id, ok := pu.symbols[coin.Symbol]; ok {
+		pu.mu.Unlock()
+		return fmt.Errorf("%w: symbol %s is tracked as %s", priceUpdater.ErrAlreadyTracked, coin.Symbol, id)
+	}
+	tc := &trackedCoin{
+		info:       coin,
+		enabled:    pu.autoEnabled,
+		interval:   pu.autoUpdate,
+		nextUpdate: time.Now().Add(pu.autoUpdate),
+	}
+	if err := pu.store.SaveTrackedCoin(tc.record()); err != nil {
+		pu.mu.Unlock()
+		return err
+	}
+	pu.coins[coin.ID] = tc
+	pu.symbols[coin.Symbol] = coin.ID
+	pu.mu.Unlock()
+	pu.reschedule()
+
+	pu.fetchPrices([]priceSource.CoinInfo{coin})
 	return nil
 }
 
-func (pu *priceUpdaterInternal) StopUpdating() error {
-	pu.wg.Add(pu.numWorkers)
-	for _, coin := range pu.coins {
-		pu.chUpdate[coin.Symbol] <- time.Duration(0)
+func (pu *priceUpdaterInternal) DeleteCryptoTracking(Symbol string) error {
+	pu.mu.Lock()
+	defer pu.mu.Unlock()
+	coin, ok := pu.coinBySymbol(Symbol)
+	if !ok {
+		return fmt.Errorf("symbol %s is %w", Symbol, priceUpdater.ErrNotTracked)
 	}
-	pu.wg.Wait()
-	if val := pu.GetUpdateTime(); val != 0*time.Second {
-		return fmt.Errorf("updating was not stopped")
+	return pu.untrack(coin)
+}
+
+func (pu *priceUpdaterInternal) DeleteCryptoTrackingByID(ID string) (priceSource.CoinInfo, error) {
+	pu.mu.Lock()
+	defer pu.mu.Unlock()
+	coin, ok := pu.coins[ID]
+	if !ok {
+		return priceSource.CoinInfo{}, fmt.Errorf("coin %s is %w", ID, priceUpdater.ErrNotTracked)
+	}
+	return coin.info, pu.untrack(coin)
+}
+
+// untrack removes coin from tracking. Callers hold pu.mu.
+func (pu *priceUpdaterInternal) untrack(coin *trackedCoin) error {
+	if err := pu.store.DeleteTrackedCoin(coin.info.ID); err != nil {
+		return err
 	}
+	delete(pu.coins, coin.info.ID)
+	delete(pu.symbols, coin.info.Symbol)
 	return nil
 }
 
-func (pu *priceUpdaterInternal) GetUpdateTime() time.Duration {
-	return pu.autoUpdate
+func (pu *priceUpdaterInternal) coinBySymbol(Symbol string) (*trackedCoin, bool) {
+	id, ok := pu.symbols[Symbol]
+	if !ok {
+		return nil, false
+	}
+	coin, ok := pu.coins[id]
+	return coin, ok
 }
 
 func (pu *priceUpdaterInternal) GetLastUpdated() time.Time {
+	pu.mu.RLock()
+	defer pu.mu.RUnlock()
 	return pu.lastUpdate
 }
 
-func (pu *priceUpdaterInternal) ChangeUpdateTime(t time.Duration) error {
-	pu.wg.Add(pu.numWorkers)
+func (pu *priceUpdaterInternal) trackedCoins() []priceSource.CoinInfo {
+	pu.mu.RLock()
+	defer pu.mu.RUnlock()
+	res := make([]priceSource.CoinInfo, 0, len(pu.coins))
 	for _, coin := range pu.coins {
-		pu.chUpdate[coin.Symbol] <- t
-	}
-	pu.wg.Wait()
-	if val := pu.GetUpdateTime(); val != t*time.Second {
-		return fmt.Errorf("update time was not changed")
+		res = append(res, coin.info)
 	}
-	return nil
+	return res
 }
 
-func (pu *priceUpdaterInternal) work(coin priceUpdater.CoinInfo) {
-	pu.getPrice(coin)
-	pu.wg.Done()
-	ticker := time.NewTicker(pu.autoUpdate)
-	defer ticker.Stop()
+func (pu *priceUpdaterInternal) work(ctx context.Context) {
+	timer := time.NewTimer(pu.untilNextUpdate(time.Now()))
+	defer func() {
+		timer.Stop()
+		close(pu.chWorkDone)
+	}()
 	for {
 		select {
-		case <-getTickerChan(ticker):
-			pu.getPrice(coin)
-		case t := <-pu.chUpdate[coin.Symbol]:
-			if ticker != nil {
-				ticker.Stop()
-			}
-			if t > 0 {
-				ticker = time.NewTicker(t * time.Second)
-				pu.autoUpdate = t * time.Second
-			} else {
-				ticker = nil
-				pu.autoUpdate = t
-			}
-			pu.wg.Done()
-		case <-pu.chRefresh[coin.Symbol]:
-			pu.getPrice(coin)
-			pu.wg.Done()
-		case <-pu.chDelete[coin.Symbol]:
-			close(pu.chDelete[coin.Symbol])
-			close(pu.chUpdate[coin.Symbol])
-			close(pu.chRefresh[coin.Symbol])
-			pu.numWorkers--
+		case <-ctx.Done():
+			return
+		case <-pu.chStop:
 			return
+		case <-timer.C:
+			pu.fetchPrices(pu.dueCoins(time.Now()))
+		case <-pu.chReschedule:
 		}
+		timer.Reset(pu.untilNextUpdate(time.Now()))
 	}
 }
-func getTickerChan(t *time.Ticker) <-chan time.Time {
-	if t == nil {
-		return nil
-	}
-	return t.C
-}
-func (pu *priceUpdaterInternal) searcherDaemon() {
-	for val := range pu.chCoins {
 
-		if _, ok := pu.coins[val]; ok {
-			pu.wg.Done()
-			pu.chErrorSearcherDaemon <- fmt.Errorf("this coin already exists: %s", val)
-			continue
+func (pu *priceUpdaterInternal) fetchPrices(coins []priceSource.CoinInfo) map[string]error {
+	errs := make(map[string]error)
+	pu.mu.RLock()
+	if pu.stopped {
+		pu.mu.RUnlock()
+		for _, coin := range coins {
+			errs[coin.Symbol] = priceUpdater.ErrStopped
 		}
+		return errs
+	}
+	ctx := pu.ctx
+	pu.inFlight.Add(1)
+	pu.mu.RUnlock()
+	defer pu.inFlight.Done()
 
-		var pathInfo = fmt.Sprintf("/api/v3/search?query=%s", strings.ToLower(val))
+	for start := 0; start < len(coins); start += pu.batchSize {
+		batch := coins[start:min(start+pu.batchSize, len(coins))]
 
-		resp, err := http.Get(addr + pathInfo)
+		prices, err := pu.source.GetPrices(ctx, batch, pu.currencies)
 		if err != nil {
-			pu.wg.Done()
-			pu.chErrorSearcherDaemon <- err
+			now := time.Now()
+			for _, coin := range batch {
+				errs[coin.Symbol] = fmt.Errorf("worker %s: %q", coin.Symbol, err)
+				pu.markFailed(coin.ID, errs[coin.Symbol], now)
+			}
 			continue
 		}
 
-		type searchResponse struct {
-			Coins []priceUpdater.CoinInfo `json:"coins"`
-		}
-
-		var searchRes searchResponse
-		if err := json.NewDecoder(resp.Body).Decode(&searchRes); err != nil {
-			pu.wg.Done()
-			pu.chErrorSearcherDaemon <- err
-			continue
-		}
-		resp.Body.Close()
-		fl := false
-		for _, coin := range searchRes.Coins {
-			if coin.Symbol == val {
-				fl = true
-				pu.numWorkers++
-				pu.chUpdate[coin.Symbol] = make(chan time.Duration)
-				pu.chRefresh[coin.Symbol] = make(chan struct{})
-				pu.chDelete[coin.Symbol] = make(chan struct{})
-				pu.coins[val] = coin
-				go pu.work(coin)
+		now := time.Now()
+		updated := 0
+		for _, coin := range batch {
+			var missing []string
+			for _, currency := range pu.currencies {
+				price, ok := prices[coin.ID][currency]
+				if !ok {
+					missing = append(missing, currency)
+					continue
+				}
+				if err := pu.store.AddCrypto(coin.Symbol, coin.Name, currency, price, now); err != nil {
+					errs[coin.Symbol] = fmt.Errorf("worker %s: %q", coin.Symbol, err)
+					break
+				}
+			}
+			if err, ok := errs[coin.Symbol]; ok {
+				pu.markFailed(coin.ID, err, now)
+				continue
+			}
+			if len(missing) > 0 {
+				errs[coin.Symbol] = fmt.Errorf("worker %s: no %s price returned for %s",
+					coin.Symbol, strings.Join(missing, ","), coin.ID)
+				pu.markFailed(coin.ID, errs[coin.Symbol], now)
+				continue
 			}
+			pu.markUpdated(coin.ID, now)
+			updated++
 		}
-		if fl == false {
-			pu.wg.Done()
-			pu.chErrorSearcherDaemon <- fmt.Errorf("there is no coin: %s", val)
-			continue
+		if updated > 0 {
+			pu.mu.Lock()
+			pu.lastUpdate = now
+			pu.mu.Unlock()
 		}
-		pu.chErrorSearcherDaemon <- nil
 	}
-}
-
-func (pu *priceUpdaterInternal) getPrice(coin priceUpdater.CoinInfo) {
-	var pathPrice = fmt.Sprintf("/api/v3/simple/price?ids=%s&vs_currencies=usd&x_cg_demo_api_key=%s",
-		coin.ID, pu.apiKey)
-	resp, err := http.Get(addr + pathPrice)
-	if err != nil {
-		pu.chErrorWorkers <- fmt.Errorf("worker %s: %q", coin.Symbol, err)
-		return
-	}
-
-	var prices map[string]map[string]float64
-	if err := json.NewDecoder(resp.Body).Decode(&prices); err != nil {
-		pu.chErrorWorkers <- fmt.Errorf("worker %s: %q", coin.Symbol, err)
-		return
+	for _, err := range errs {
+		pu.chErrorWorkers <- err
 	}
-	price := prices[coin.ID]["usd"]
-	if err := pu.store.AddCrypto(coin.Symbol, coin.Name, price, time.Now()); err != nil {
-		pu.chErrorWorkers <- fmt.Errorf("worker %s: %q", coin.Symbol, err)
-		return
-	}
-	resp.Body.Close()
-	pu.mu.Lock()
-	pu.lastUpdate = time.Now()
-	pu.mu.Unlock()
+	return errs
 }
 
 func (pu *priceUpdaterInternal) errorHandler() {
+	defer close(pu.chErrorsDone)
 	for val := range pu.chErrorWorkers {
-		fmt.Println(val)
+		log.Println(val)
 	}
 }
@@ -0,0 +1,440 @@
+package priceUpdaterMultithreaded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/priceSource"
+	"github.com/zenrot/CryptoService/internal/priceSource/fakeSource"
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
+	"github.com/zenrot/CryptoService/internal/storage"
+	"github.com/zenrot/CryptoService/internal/storage/ramstore"
+)
+
+func TestPriceUpdaterFakeSource(t *testing.T) {
+	store, err := ramstore.NewRamStorage(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := fakeSource.New()
+	pu := New(&config.Config{PriceSourceConfig: config.PriceSourceConfig{QuoteCurrencies: []string{"usd", "EUR"}}}, store, source)
+	if err := pu.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer pu.Shutdown(context.Background())
+
+	if _, err := pu.AddCryptoTracking("btc"); err != nil {
+		t.Fatal("AddCryptoTracking err:", err)
+	}
+	latest, err := store.GetLatestCrypto("usd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := latest["btc"].Price; got != 50000 {
+		t.Errorf("btc price = %v, want 50000", got)
+	}
+
+	latest, _ = store.GetLatestCrypto("eur")
+	if got := latest["btc"].Price; got != 25000 {
+		t.Errorf("btc eur price = %v, want 25000", got)
+	}
+
+	source.SetPrice("bitcoin", 51000)
+	if err := pu.RefreshPrice("btc"); err != nil {
+		t.Fatal("RefreshPrice err:", err)
+	}
+	latest, _ = store.GetLatestCrypto("usd")
+	if got := latest["btc"].Price; got != 51000 {
+		t.Errorf("btc price after refresh = %v, want 51000", got)
+	}
+
+	if _, err := pu.AddCryptoTracking("btc"); !errors.Is(err, priceUpdater.ErrAlreadyTracked) {
+		t.Errorf("tracking btc twice: got %v, want ErrAlreadyTracked", err)
+	}
+	if _, err := pu.AddCryptoTracking("nope"); !errors.Is(err, priceSource.ErrUnknownCoin) {
+		t.Errorf("unknown symbol: got %v, want ErrUnknownCoin", err)
+	}
+	if err := pu.RefreshPrice("nope"); !errors.Is(err, priceUpdater.ErrNotTracked) {
+		t.Errorf("refresh untracked: got %v, want ErrNotTracked", err)
+	}
+}
+
+type countingSource struct {
+	priceSource.PriceSource
+	batches int
+}
+
+func (cs *countingSource) GetPrices(ctx context.Context, coins []priceSource.CoinInfo, currencies []string) (map[string]map[string]float64, error) {
+	cs.batches++
+	res, err := cs.PriceSource.GetPrices(ctx, coins, currencies)
+	if err != nil {
+		return nil, err
+	}
+	delete(res, "coin-4")
+	return res, nil
+}
+
+func TestPriceUpdaterBatching(t *testing.T) {
+	store, err := ramstore.NewRamStorage(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := fakeSource.New()
+	for i := 0; i < 7; i++ {
+		fake.AddCoin(priceSource.CoinInfo{
+			ID:     fmt.Sprintf("coin-%d", i),
+			Symbol: fmt.Sprintf("c%d", i),
+			Name:   fmt.Sprintf("Coin %d", i),
+		}, float64(i+1))
+	}
+	source := &countingSource{PriceSource: fake}
+	pu := New(&config.Config{PriceSourceConfig: config.PriceSourceConfig{MaxBatchSize: 3}}, store, source)
+	if err := pu.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer pu.Shutdown(context.Background())
+	if err := pu.StopUpdating(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 7; i++ {
+		if _, err := pu.AddCryptoTracking(fmt.Sprintf("c%d", i)); err != nil {
+			t.Fatal("AddCryptoTracking err:", err)
+		}
+	}
+
+	source.batches = 0
+	updated, err := pu.RefreshAllPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source.batches != 3 {
+		t.Errorf("provider calls = %d, want 3", source.batches)
+	}
+	if updated != 6 {
+		t.Errorf("updated = %d, want 6", updated)
+	}
+	if err := pu.RefreshPrice("c4"); err == nil {
+		t.Error("expected per-coin error for c4")
+	}
+	if err := pu.RefreshPrice("c3"); err != nil {
+		t.Error("unexpected error for c3:", err)
+	}
+}
+
+func TestPriceUpdaterShutdown(t *testing.T) {
+	store, err := ramstore.NewRamStorage(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pu := New(&config.Config{}, store, fakeSource.New())
+	if err := pu.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := pu.Start(context.Background()); !errors.Is(err, priceUpdater.ErrAlreadyStarted) {
+		t.Errorf("second Start err = %v, want ErrAlreadyStarted", err)
+	}
+	if _, err := pu.AddCryptoTracking("eth"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := pu.Shutdown(ctx); err != nil {
+		t.Fatal("Shutdown err:", err)
+	}
+
+	if err := pu.RefreshPrice("eth"); !errors.Is(err, priceUpdater.ErrStopped) {
+		t.Errorf("RefreshPrice after shutdown err = %v, want ErrStopped", err)
+	}
+	if _, err := pu.AddCryptoTracking("btc"); !errors.Is(err, priceUpdater.ErrStopped) {
+		t.Errorf("AddCryptoTracking after shutdown err = %v, want ErrStopped", err)
+	}
+	if err := pu.StopUpdating(); !errors.Is(err, priceUpdater.ErrStopped) {
+		t.Errorf("StopUpdating after shutdown err = %v, want ErrStopped", err)
+	}
+	if err := pu.Shutdown(ctx); err != nil {
+		t.Error("second Shutdown err:", err)
+	}
+}
+
+func TestPriceUpdaterPerSymbolSchedule(t *testing.T) {
+	store, err := ramstore.NewRamStorage(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pu := New(&config.Config{}, store, fakeSource.New())
+	if err := pu.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer pu.Shutdown(context.Background())
+	if err := pu.StopUpdating(); err != nil {
+		t.Fatal(err)
+	}
+	for _, symbol := range []string{"btc", "eth"} {
+		if _, err := pu.AddCryptoTracking(symbol); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := pu.SetSchedule("btc", true, 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := pu.SetSchedule("doge", true, time.Second); err == nil {
+		t.Error("expected error for untracked symbol")
+	}
+	time.Sleep(150 * time.Millisecond)
+
+	btc, _ := store.GetCrypto("btc", "usd", storage.HistoryQuery{})
+	eth, _ := store.GetCrypto("eth", "usd", storage.HistoryQuery{})
+	if len(btc.Values) < 3 {
+		t.Errorf("btc records = %d, want at least 3", len(btc.Values))
+	}
+	if len(eth.Values) != 1 {
+		t.Errorf("eth records = %d, want 1", len(eth.Values))
+	}
+
+	schedules := pu.GetSchedules()
+	if s := schedules["btc"]; !s.Enabled || !s.Custom || s.Interval != 20*time.Millisecond {
+		t.Errorf("btc schedule = %+v", s)
+	}
+	if s := schedules["eth"]; s.Enabled || s.Custom {
+		t.Errorf("eth schedule = %+v", s)
+	}
+
+	if err := pu.ChangeUpdateTime(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	for symbol, s := range pu.GetSchedules() {
+		if !s.Enabled || s.Custom || s.Interval != time.Minute {
+			t.Errorf("%s schedule after default change = %+v", symbol, s)
+		}
+	}
+}
+
+func TestPriceUpdaterDuplicateSymbols(t *testing.T) {
+	store, err := ramstore.NewRamStorage(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pu := New(&config.Config{}, store, fakeSource.New())
+	if err := pu.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer pu.Shutdown(context.Background())
+
+	coin, err := pu.AddCryptoTracking("eth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coin.ID != "ethereum" {
+		t.Errorf("auto-picked %s, want highest ranked ethereum", coin.ID)
+	}
+	if _, err := pu.AddCryptoTrackingByID("ethereum-wormhole"); err == nil {
+		t.Error("expected conflict for a second coin with the same symbol")
+	}
+	if err := pu.DeleteCryptoTracking("eth"); err != nil {
+		t.Fatal(err)
+	}
+	coin, err = pu.AddCryptoTrackingByID("ethereum-wormhole")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coin.Symbol != "eth" || coin.Name != "Ethereum (Wormhole)" {
+		t.Errorf("tracked coin = %+v", coin)
+	}
+	if len(pu.GetSchedules()) != 1 {
+		t.Errorf("tracked coins = %d, want 1", len(pu.GetSchedules()))
+	}
+
+	coin, err = pu.DeleteCryptoTrackingByID("ethereum-wormhole")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coin.Symbol != "eth" || len(pu.GetSchedules()) != 0 {
+		t.Errorf("deleted %+v, tracked coins = %d", coin, len(pu.GetSchedules()))
+	}
+	if _, err := pu.DeleteCryptoTrackingByID("ethereum-wormhole"); err == nil {
+		t.Error("expected error for a coin that is not tracked")
+	}
+}
+
+func TestPriceUpdaterRestoresTracking(t *testing.T) {
+	store, err := ramstore.NewRamStorage(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pu := New(&config.Config{}, store, fakeSource.New())
+	if err := pu.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := pu.ChangeUpdateTime(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pu.AddCryptoTrackingByID("ethereum-wormhole"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pu.AddCryptoTracking("btc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pu.AddCryptoTracking("sol"); err != nil {
+		t.Fatal(err)
+	}
+	if err := pu.SetSchedule("btc", false, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := pu.DeleteCryptoTracking("sol"); err != nil {
+		t.Fatal(err)
+	}
+	if err := pu.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := New(&config.Config{}, store, fakeSource.New())
+	if err := restarted.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.Shutdown(context.Background())
+
+	if got := restarted.GetUpdateTime(); got != time.Hour {
+		t.Errorf("default interval = %v, want 1h", got)
+	}
+	schedules := restarted.GetSchedules()
+	if len(schedules) != 2 {
+		t.Fatalf("restored coins = %d, want 2", len(schedules))
+	}
+	if s := schedules["btc"]; s.Enabled || !s.Custom || s.Interval != time.Hour {
+		t.Errorf("btc schedule = %+v", s)
+	}
+	if s := schedules["eth"]; !s.Enabled || s.Custom || s.Interval != time.Hour {
+		t.Errorf("eth schedule = %+v", s)
+	}
+
+	before, _ := store.GetCrypto("eth", "usd", storage.HistoryQuery{})
+	time.Sleep(50 * time.Millisecond)
+	after, _ := store.GetCrypto("eth", "usd", storage.HistoryQuery{})
+	if len(after.Values) != len(before.Values)+1 {
+		t.Errorf("eth records after restart = %d, want %d", len(after.Values), len(before.Values)+1)
+	}
+	if _, err := restarted.AddCryptoTracking("eth"); err == nil {
+		t.Error("expected restored eth to conflict")
+	}
+}
+
+type flakySource struct {
+	priceSource.PriceSource
+	mu   sync.Mutex
+	fail bool
+}
+
+func (fs *flakySource) setFail(fail bool) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.fail = fail
+}
+
+func (fs *flakySource) GetPrices(ctx context.Context, coins []priceSource.CoinInfo, currencies []string) (map[string]map[string]float64, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.fail {
+		return nil, errors.New("provider unavailable")
+	}
+	return fs.PriceSource.GetPrices(ctx, coins, currencies)
+}
+
+func TestPriceUpdaterHealth(t *testing.T) {
+	store, err := ramstore.NewRamStorage(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &flakySource{PriceSource: fakeSource.New(), fail: true}
+	pu := New(&config.Config{}, store, source)
+	if err := pu.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer pu.Shutdown(context.Background())
+	if err := pu.StopUpdating(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pu.GetHealth("btc"); err == nil {
+		t.Error("expected error for untracked symbol")
+	}
+	if _, err := pu.AddCryptoTracking("btc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := pu.RefreshPrice("btc"); err == nil {
+		t.Fatal("expected refresh to fail")
+	}
+	h, err := pu.GetHealth("btc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Status != priceUpdater.StatusFailing || h.ConsecutiveFailures != 2 || !h.Stale || h.LastError == "" {
+		t.Errorf("health after failures = %+v", h)
+	}
+
+	source.setFail(false)
+	if err := pu.RefreshPrice("btc"); err != nil {
+		t.Fatal(err)
+	}
+	h = pu.GetHealths()["btc"]
+	if h.Status != priceUpdater.StatusOK || h.ConsecutiveFailures != 0 || h.Stale || h.LastSuccess.IsZero() {
+		t.Errorf("health after success = %+v", h)
+	}
+	if h.LastError == "" {
+		t.Error("last error should be kept after recovery")
+	}
+}
+
+type blockingSource struct {
+	priceSource.PriceSource
+	block   bool
+	started chan struct{}
+}
+
+func (bs *blockingSource) GetPrices(ctx context.Context, coins []priceSource.CoinInfo, currencies []string) (map[string]map[string]float64, error) {
+	if !bs.block {
+		return bs.PriceSource.GetPrices(ctx, coins, currencies)
+	}
+	close(bs.started)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestPriceUpdaterShutdownCancelsFetch(t *testing.T) {
+	store, err := ramstore.NewRamStorage(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &blockingSource{PriceSource: fakeSource.New(), started: make(chan struct{})}
+	pu := New(&config.Config{}, store, source)
+	if err := pu.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := pu.StopUpdating(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pu.AddCryptoTracking("btc"); err != nil {
+		t.Fatal(err)
+	}
+
+	source.block = true
+	refreshed := make(chan error, 1)
+	go func() { refreshed <- pu.RefreshPrice("btc") }()
+	<-source.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := pu.Shutdown(ctx); err != nil {
+		t.Fatal("shutdown did not cancel the in-flight fetch:", err)
+	}
+	if err := <-refreshed; err == nil {
+		t.Error("expected the cancelled refresh to fail")
+	}
+}
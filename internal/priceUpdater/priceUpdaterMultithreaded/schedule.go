@@ -0,0 +1,186 @@
+package priceUpdaterMultithreaded
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/priceSource"
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func (pu *priceUpdaterInternal) GetUpdateTime() time.Duration {
+	pu.mu.RLock()
+	defer pu.mu.RUnlock()
+	if !pu.autoEnabled {
+		return 0
+	}
+	return pu.autoUpdate
+}
+
+func (pu *priceUpdaterInternal) ChangeUpdateTime(t time.Duration) error {
+	if t <= 0 {
+		return fmt.Errorf("update time must be positive")
+	}
+	pu.mu.Lock()
+	if pu.stopped {
+		pu.mu.Unlock()
+		return priceUpdater.ErrStopped
+	}
+	pu.autoUpdate = t
+	pu.autoEnabled = true
+	now := time.Now()
+	for _, coin := range pu.coins {
+		coin.enabled = true
+		coin.interval = t
+		coin.custom = false
+		coin.nextUpdate = now.Add(t)
+	}
+	err := pu.persistSchedules()
+	pu.mu.Unlock()
+	pu.reschedule()
+	return err
+}
+
+func (pu *priceUpdaterInternal) StopUpdating() error {
+	pu.mu.Lock()
+	if pu.stopped {
+		pu.mu.Unlock()
+		return priceUpdater.ErrStopped
+	}
+	pu.autoEnabled = false
+	for _, coin := range pu.coins {
+		coin.enabled = false
+		coin.interval = pu.autoUpdate
+		coin.custom = false
+	}
+	err := pu.persistSchedules()
+	pu.mu.Unlock()
+	pu.reschedule()
+	return err
+}
+
+func (pu *priceUpdaterInternal) GetSchedule(Symbol string) (priceUpdater.Schedule, error) {
+	pu.mu.RLock()
+	defer pu.mu.RUnlock()
+	coin, ok := pu.coinBySymbol(Symbol)
+	if !ok {
+		return priceUpdater.Schedule{}, fmt.Errorf("symbol %s is %w", Symbol, priceUpdater.ErrNotTracked)
+	}
+	return coin.schedule(), nil
+}
+
+func (pu *priceUpdaterInternal) GetSchedules() map[string]priceUpdater.Schedule {
+	pu.mu.RLock()
+	defer pu.mu.RUnlock()
+	res := make(map[string]priceUpdater.Schedule, len(pu.coins))
+	for _, coin := range pu.coins {
+		res[coin.info.Symbol] = coin.schedule()
+	}
+	return res
+}
+
+func (pu *priceUpdaterInternal) SetSchedule(Symbol string, enabled bool, interval time.Duration) error {
+	pu.mu.Lock()
+	if pu.stopped {
+		pu.mu.Unlock()
+		return priceUpdater.ErrStopped
+	}
+	coin, ok := pu.coinBySymbol(Symbol)
+	if !ok {
+		pu.mu.Unlock()
+		return fmt.Errorf("symbol %s is %w", Symbol, priceUpdater.ErrNotTracked)
+	}
+	coin.enabled = enabled
+	if interval > 0 {
+		coin.interval = interval
+	}
+	coin.custom = true
+	coin.nextUpdate = time.Now().Add(coin.interval)
+	err := pu.store.SaveTrackedCoin(coin.record())
+	pu.mu.Unlock()
+	pu.reschedule()
+	return err
+}
+
+func (coin *trackedCoin) schedule() priceUpdater.Schedule {
+	return priceUpdater.Schedule{
+		Enabled:     coin.enabled,
+		Interval:    coin.interval,
+		Custom:      coin.custom,
+		LastUpdated: coin.lastUpdated,
+		NextUpdate:  coin.nextUpdate,
+	}
+}
+
+func (coin *trackedCoin) record() storage.TrackedCoin {
+	return storage.TrackedCoin{
+		ID:       coin.info.ID,
+		Symbol:   coin.info.Symbol,
+		Name:     coin.info.Name,
+		Enabled:  coin.enabled,
+		Interval: coin.interval,
+		Custom:   coin.custom,
+	}
+}
+
+func (pu *priceUpdaterInternal) persistSchedules() error {
+	err := pu.store.SaveDefaultSchedule(storage.ScheduleSettings{
+		Enabled:  pu.autoEnabled,
+		Interval: pu.autoUpdate,
+	})
+	if err != nil {
+		return err
+	}
+	for _, coin := range pu.coins {
+		if err := pu.store.SaveTrackedCoin(coin.record()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (pu *priceUpdaterInternal) reschedule() {
+	select {
+	case pu.chReschedule <- struct{}{}:
+	default:
+	}
+}
+
+func (pu *priceUpdaterInternal) untilNextUpdate(now time.Time) time.Duration {
+	pu.mu.RLock()
+	defer pu.mu.RUnlock()
+	wait := idleWakeup
+	for _, coin := range pu.coins {
+		if !coin.enabled || coin.interval <= 0 {
+			continue
+		}
+		if d := coin.nextUpdate.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	return max(wait, 0)
+}
+
+func (pu *priceUpdaterInternal) dueCoins(now time.Time) []priceSource.CoinInfo {
+	pu.mu.Lock()
+	defer pu.mu.Unlock()
+	res := make([]priceSource.CoinInfo, 0)
+	for _, coin := range pu.coins {
+		if !coin.enabled || coin.interval <= 0 || coin.nextUpdate.After(now) {
+			continue
+		}
+		coin.nextUpdate = now.Add(coin.interval)
+		res = append(res, coin.info)
+	}
+	return res
+}
+
+func (pu *priceUpdaterInternal) markUpdated(ID string, t time.Time) {
+	pu.mu.Lock()
+	defer pu.mu.Unlock()
+	if coin, ok := pu.coins[ID]; ok {
+		coin.lastUpdated = t
+		coin.failures = 0
+	}
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"time"
+)
+
+type HistoryQuery struct {
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Cursor string
+}
+
+type HistoryPage struct {
+	Values     []CryptoVal
+	NextCursor string
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor returns an opaque cursor pointing right after a sample taken at t.
+func EncodeCursor(t time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(t.UnixNano(), 10)))
+}
+
+func DecodeCursor(cursor string) (time.Time, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	return time.Unix(0, nanos), nil
+}
+
+// After returns the exclusive lower bound set by the cursor, if any.
+func (q HistoryQuery) After() (time.Time, bool, error) {
+	if q.Cursor == "" {
+		return time.Time{}, false, nil
+	}
+	t, err := DecodeCursor(q.Cursor)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return t, true, nil
+}
@@ -0,0 +1,102 @@
+package postgresStorage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func (st *postgresStorage) SaveAlertRule(rule storage.AlertRule) error {
+	_, err := st.db.Exec(`INSERT INTO alert_rules
+		(rule_id, user_name, symbol, currency, rule_type, threshold, window_ms, cooldown_ms, triggered, changed_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (rule_id) DO UPDATE
+		SET triggered = EXCLUDED.triggered, changed_at = EXCLUDED.changed_at`,
+		rule.ID, rule.User, rule.Symbol, rule.Currency, rule.Type, rule.Threshold,
+		rule.Window.Milliseconds(), rule.Cooldown.Milliseconds(), rule.Triggered,
+		sql.NullTime{Time: rule.ChangedAt, Valid: !rule.ChangedAt.IsZero()}, rule.CreatedAt)
+	return err
+}
+
+func (st *postgresStorage) DeleteAlertRule(user, id string) error {
+	res, err := st.db.Exec(`DELETE FROM alert_rules WHERE rule_id = $1 AND user_name = $2`, id, user)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return storage.ErrAlertNotExists
+	}
+	return nil
+}
+
+func (st *postgresStorage) GetAlertRules(user string) ([]storage.AlertRule, error) {
+	rows, err := st.db.Query(`SELECT rule_id, user_name, symbol, currency, rule_type, threshold,
+		       window_ms, cooldown_ms, triggered, changed_at, created_at
+		FROM alert_rules
+		WHERE $1 = '' OR user_name = $1
+		ORDER BY created_at, rule_id`, user)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]storage.AlertRule, 0)
+	for rows.Next() {
+		var rule storage.AlertRule
+		var windowMs, cooldownMs int64
+		var changedAt sql.NullTime
+		if err := rows.Scan(&rule.ID, &rule.User, &rule.Symbol, &rule.Currency, &rule.Type, &rule.Threshold,
+			&windowMs, &cooldownMs, &rule.Triggered, &changedAt, &rule.CreatedAt); err != nil {
+			return nil, err
+		}
+		rule.Window = time.Duration(windowMs) * time.Millisecond
+		rule.Cooldown = time.Duration(cooldownMs) * time.Millisecond
+		rule.ChangedAt = changedAt.Time
+		res = append(res, rule)
+	}
+	return res, rows.Err()
+}
+
+func (st *postgresStorage) AddAlertEvent(event storage.AlertEvent) error {
+	res, err := st.db.Exec(`INSERT INTO alert_events (rule_id, state, price, message, timestamp)
+		SELECT rule_id, $2::text, $3::double precision, $4::text, $5::timestamptz
+		FROM alert_rules WHERE rule_id = $1`,
+		event.RuleID, event.State, event.Price, event.Message, event.Time)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return storage.ErrAlertNotExists
+	}
+	return nil
+}
+
+func (st *postgresStorage) GetAlertEvents(ruleID string, limit int) ([]storage.AlertEvent, error) {
+	query := `SELECT rule_id, state, price, message, timestamp
+		FROM alert_events
+		WHERE rule_id = $1
+		ORDER BY timestamp DESC, event_id DESC`
+	args := []any{ruleID}
+	if limit > 0 {
+		query += " LIMIT $2"
+		args = append(args, limit)
+	}
+	rows, err := st.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]storage.AlertEvent, 0)
+	for rows.Next() {
+		var event storage.AlertEvent
+		if err := rows.Scan(&event.RuleID, &event.State, &event.Price, &event.Message, &event.Time); err != nil {
+			return nil, err
+		}
+		res = append(res, event)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,61 @@
+package postgresStorage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func (st *postgresStorage) GetCandles(symbol, currency string, interval time.Duration, from, to time.Time) ([]storage.Candle, error) {
+	st.mu.RLock()
+	id, ok := st.symbToIDmap[symbol]
+	st.mu.RUnlock()
+	if !ok {
+		return nil, storage.NotTracked(symbol)
+	}
+
+	args := []any{id, currency, int64(interval / time.Second)}
+	filter := ""
+	if !from.IsZero() {
+		args = append(args, from)
+		filter += fmt.Sprintf(" AND ts >= $%d", len(args))
+	}
+	if !to.IsZero() {
+		args = append(args, to)
+		filter += fmt.Sprintf(" AND ts <= $%d", len(args))
+	}
+
+	rows, err := st.db.Query(`WITH bucketed AS (
+		SELECT ts, open, high, low, close, samples,
+		       to_timestamp(floor(extract(epoch FROM ts)::double precision / $3::double precision)
+		                    * $3::double precision) AT TIME ZONE 'UTC' AS bucket
+		FROM `+series(st.resolution(from))+` AS s
+		WHERE true`+filter+`
+	)
+	SELECT DISTINCT bucket,
+	       first_value(open) OVER w,
+	       max(high) OVER w,
+	       min(low) OVER w,
+	       last_value(close) OVER w,
+	       sum(samples) OVER w
+	FROM bucketed
+	WINDOW w AS (PARTITION BY bucket ORDER BY ts
+	             ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING)
+	ORDER BY bucket`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]storage.Candle, 0)
+	for rows.Next() {
+		var c storage.Candle
+		if err := rows.Scan(&c.Time, &c.Open, &c.High, &c.Low, &c.Close, &c.SampleCount); err != nil {
+			return nil, err
+		}
+		c.Time = c.Time.UTC()
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
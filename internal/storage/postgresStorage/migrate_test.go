@@ -0,0 +1,68 @@
+package postgresStorage
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, mig := range migrations {
+		if mig.version != i+1 || mig.up == "" || mig.down == "" {
+			t.Errorf("migration %d = %+v", i, mig)
+		}
+	}
+
+	broken := []fstest.MapFS{
+		{"migrations/0001_init.up.sql": {Data: []byte("SELECT 1")}},
+		{
+			"migrations/0001_init.up.sql":   {Data: []byte("SELECT 1")},
+			"migrations/0001_init.down.sql": {Data: []byte("SELECT 1")},
+			"migrations/0003_skip.up.sql":   {Data: []byte("SELECT 1")},
+			"migrations/0003_skip.down.sql": {Data: []byte("SELECT 1")},
+		},
+		{"migrations/init.up.sql": {Data: []byte("SELECT 1")}},
+	}
+	for i, fsys := range broken {
+		if _, err := loadMigrations(fsys); err == nil {
+			t.Errorf("broken set %d: expected error", i)
+		}
+	}
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	m, err := NewMigrator(testConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	ctx := context.Background()
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := m.Up(ctx); err != nil || n != 0 {
+		t.Errorf("second Up = %d, %v", n, err)
+	}
+	last := m.migrations[len(m.migrations)-1]
+	if n, err := m.Down(ctx, 1); err != nil || n != 1 {
+		t.Fatalf("Down = %d, %v", n, err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := statuses[len(statuses)-1]; s.Version != last.version || s.Applied {
+		t.Errorf("status after down = %+v", s)
+	}
+	if n, err := m.Up(ctx); err != nil || n != 1 {
+		t.Errorf("Up after down = %d, %v", n, err)
+	}
+}
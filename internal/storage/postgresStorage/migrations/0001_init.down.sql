@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS crypto_prices;
+DROP TABLE IF EXISTS crypto_info;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS users (
+    user_name text NOT NULL UNIQUE,
+    password text NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(user_name)
+);
+
+CREATE TABLE IF NOT EXISTS crypto_info (
+    crypto_id serial PRIMARY KEY,
+    name text NOT NULL UNIQUE,
+    symbol text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS crypto_prices (
+    crypto_id int NOT NULL,
+    price float NOT NULL,
+    timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(crypto_id) REFERENCES crypto_info(crypto_id)
+);
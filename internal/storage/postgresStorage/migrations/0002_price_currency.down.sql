@@ -0,0 +1 @@
+ALTER TABLE crypto_prices DROP COLUMN IF EXISTS currency;
@@ -0,0 +1 @@
+ALTER TABLE crypto_prices ADD COLUMN IF NOT EXISTS currency text NOT NULL DEFAULT 'usd';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS updater_settings;
+DROP TABLE IF EXISTS tracked_coins;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS tracked_coins (
+    coin_id text PRIMARY KEY,
+    symbol text NOT NULL UNIQUE,
+    name text NOT NULL,
+    enabled boolean NOT NULL,
+    interval_ms bigint NOT NULL,
+    custom boolean NOT NULL DEFAULT false
+);
+
+CREATE TABLE IF NOT EXISTS updater_settings (
+    id int PRIMARY KEY CHECK (id = 1),
+    enabled boolean NOT NULL,
+    interval_ms bigint NOT NULL
+);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS crypto_prices_history_idx;
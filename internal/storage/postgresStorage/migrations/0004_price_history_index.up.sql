@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS crypto_prices_history_idx
+    ON crypto_prices (crypto_id, currency, timestamp);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS crypto_rollups (
+    crypto_id int NOT NULL,
+    currency text NOT NULL,
+    resolution text NOT NULL CHECK (resolution IN ('1h', '1d')),
+    bucket timestamp NOT NULL,
+    open float NOT NULL,
+    high float NOT NULL,
+    low float NOT NULL,
+    close float NOT NULL,
+    avg_price float NOT NULL,
+    sample_count int NOT NULL,
+    PRIMARY KEY (crypto_id, currency, resolution, bucket),
+    FOREIGN KEY(crypto_id) REFERENCES crypto_info(crypto_id)
+);
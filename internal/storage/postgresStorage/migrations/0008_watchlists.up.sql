@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS watchlists (
+    watchlist_id text PRIMARY KEY,
+    user_name text NOT NULL,
+    name text NOT NULL,
+    symbols text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS watchlists_user_idx ON watchlists (user_name);
@@ -1,13 +1,16 @@
 package postgresStorage
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"math"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/zenrot/CryptoService/internal/config"
 	"github.com/zenrot/CryptoService/internal/crypt"
 	"github.com/zenrot/CryptoService/internal/storage"
@@ -18,164 +21,115 @@ type postgresStorage struct {
 	symbToIDmap    map[string]int
 	postgresConfig *config.PostgresConfig
 	db             *sql.DB
-}
 
-func NewPostgresStorageFull(cfg *config.Config) (*postgresStorage, error) {
-	pc := cfg.PostgresConfig
-	var psqlInfo string
-	if pc.Password == "" {
-		psqlInfo = fmt.Sprintf(
-			"host=%s port=%s user=%s dbname=%s sslmode=disable",
-			pc.Host, pc.Port, pc.User, pc.Dbname,
-		)
-	} else {
-		psqlInfo = fmt.Sprintf(
-			"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-			pc.Host, pc.Port, pc.User, pc.Password, pc.Dbname,
-		)
-	}
+	retention     config.RetentionConfig
+	stopCompactor chan struct{}
+	compactorDone chan struct{}
+}
 
-	db, err := sql.Open("postgres", psqlInfo)
+func NewPostgresStorage(cfg *config.Config) (*postgresStorage, error) {
+	db, err := open(cfg)
 	if err != nil {
 		return nil, err
 	}
-	err = db.Ping()
+	st, err := setup(db)
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
-
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS users (
-       user_name text NOT NULL UNIQUE,
-       password text NOT NULL,
-	   created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-       PRIMARY KEY(user_name)
-);`)
-	if err != nil {
-		return nil, err
+	st.postgresConfig = &cfg.PostgresConfig
+	st.retention = cfg.RetentionConfig
+	if st.retention.Enabled && st.retention.CompactionInterval > 0 {
+		st.stopCompactor = make(chan struct{})
+		st.compactorDone = make(chan struct{})
+		go st.compactor()
 	}
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS crypto_info (
-    crypto_id serial PRIMARY KEY,
-    name text NOT NULL UNIQUE,
-    symbol text NOT NULL
-);`)
+	return st, nil
+}
+
+func open(cfg *config.Config) (*sql.DB, error) {
+	pc := cfg.PostgresConfig
+	dsn, err := connString(&pc)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS crypto_prices (
-    crypto_id int NOT NULL,
-    price float NOT NULL,
-    timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY(crypto_id) REFERENCES crypto_info(crypto_id)
-);`)
+	db, err := sql.Open("postgres", dsn)
 	if err != nil {
 		return nil, err
 	}
-	symbToIDmap := make(map[string]int)
-	rows, err := db.Query(`SELECT crypto_id, symbol FROM crypto_info WHERE crypto_id IS NOT NULL`)
-	if err != nil {
-		return nil, err
+	if pc.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(pc.MaxOpenConns)
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var id int
-		var symbol string
-		err = rows.Scan(&id, &symbol)
-		if err != nil {
-			return nil, err
-		}
-		symbToIDmap[symbol] = id
+	if pc.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(pc.MaxIdleConns)
 	}
-	return &postgresStorage{
-		symbToIDmap:    symbToIDmap,
-		postgresConfig: &cfg.PostgresConfig,
-		db:             db,
-	}, nil
-}
-
-func NewAuth(cfg *config.Config) (*postgresStorage, error) {
-	pc := cfg.PostgresConfig
-	var psqlInfo string
-	if pc.Password == "" {
-		psqlInfo = fmt.Sprintf(
-			"host=%s port=%s user=%s dbname=%s sslmode=disable",
-			pc.Host, pc.Port, pc.User, pc.Dbname,
-		)
-	} else {
-		psqlInfo = fmt.Sprintf(
-			"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-			pc.Host, pc.Port, pc.User, pc.Password, pc.Dbname,
-		)
+	if pc.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(pc.ConnMaxLifetime)
 	}
-
-	db, err := sql.Open("postgres", psqlInfo)
-	if err != nil {
-		return nil, err
+	if pc.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(pc.ConnMaxIdleTime)
 	}
 	err = db.Ping()
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
+	return db, nil
+}
 
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS users (
-       user_name text NOT NULL UNIQUE,
-       password text NOT NULL,
-	   created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-       PRIMARY KEY(user_name)
-);`)
-	if err != nil {
-		return nil, err
+// connString builds a key=value connection string. A configured DSN (URL or
+// key=value) is the base; explicit fields override what it sets.
+func connString(pc *config.PostgresConfig) (string, error) {
+	dsn := pc.DSN
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		var err error
+		if dsn, err = pq.ParseURL(dsn); err != nil {
+			return "", err
+		}
 	}
 
-	return &postgresStorage{
-		symbToIDmap:    nil,
-		postgresConfig: &cfg.PostgresConfig,
-		db:             db,
-	}, nil
+	params := []string{dsn}
+	add := func(key, val string) {
+		if val == "" {
+			return
+		}
+		params = append(params, key+"="+quoteConnValue(val))
+	}
+	add("host", pc.Host)
+	add("port", pc.Port)
+	add("user", pc.User)
+	add("password", pc.Password)
+	add("dbname", pc.Dbname)
+	if pc.SSLMode == "" && pc.DSN == "" {
+		add("sslmode", "disable")
+	}
+	add("sslmode", pc.SSLMode)
+	add("sslrootcert", pc.SSLRootCert)
+	if pc.StatementTimeout > 0 {
+		add("statement_timeout", strconv.FormatInt(pc.StatementTimeout.Milliseconds(), 10))
+	}
+	return strings.TrimSpace(strings.Join(params, " ")), nil
 }
 
-func NewCrypto(cfg *config.Config) (*postgresStorage, error) {
-	pc := cfg.PostgresConfig
-	var psqlInfo string
-	if pc.Password == "" {
-		psqlInfo = fmt.Sprintf(
-			"host=%s port=%s user=%s dbname=%s sslmode=disable",
-			pc.Host, pc.Port, pc.User, pc.Dbname,
-		)
-	} else {
-		psqlInfo = fmt.Sprintf(
-			"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-			pc.Host, pc.Port, pc.User, pc.Password, pc.Dbname,
-		)
+func quoteConnValue(val string) string {
+	if val != "" && !strings.ContainsAny(val, ` '\`) {
+		return val
 	}
+	val = strings.ReplaceAll(val, `\`, `\\`)
+	val = strings.ReplaceAll(val, `'`, `\'`)
+	return "'" + val + "'"
+}
 
-	db, err := sql.Open("postgres", psqlInfo)
+func setup(db *sql.DB) (*postgresStorage, error) {
+	m, err := newMigrator(db)
 	if err != nil {
 		return nil, err
 	}
-	err = db.Ping()
-	if err != nil {
-		return nil, err
-	}
-
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS crypto_info (
-    crypto_id serial PRIMARY KEY,
-    name text NOT NULL UNIQUE,
-    symbol text NOT NULL
-);`)
-	if err != nil {
+	if _, err := m.Up(context.Background()); err != nil {
 		return nil, err
 	}
 
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS crypto_prices (
-    crypto_id int NOT NULL,
-    price float NOT NULL,
-    timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY(crypto_id) REFERENCES crypto_info(crypto_id)
-);`)
-	if err != nil {
-		return nil, err
-	}
 	symbToIDmap := make(map[string]int)
 	rows, err := db.Query(`SELECT crypto_id, symbol FROM crypto_info WHERE crypto_id IS NOT NULL`)
 	if err != nil {
@@ -191,13 +145,23 @@ func NewCrypto(cfg *config.Config) (*postgresStorage, error) {
 		}
 		symbToIDmap[symbol] = id
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 	return &postgresStorage{
-		symbToIDmap:    symbToIDmap,
-		postgresConfig: &cfg.PostgresConfig,
-		db:             db,
+		symbToIDmap: symbToIDmap,
+		db:          db,
 	}, nil
 }
 
+func (st *postgresStorage) Close() error {
+	if st.stopCompactor != nil {
+		close(st.stopCompactor)
+		<-st.compactorDone
+	}
+	return st.db.Close()
+}
+
 func (st *postgresStorage) RegisterUser(name, password string) error {
 	hashedPasswd, err := crypt.HashPassword(password)
 	if err != nil {
@@ -223,7 +187,7 @@ func (st *postgresStorage) LoginUser(name, password string) (*storage.User, erro
 	return &user, nil
 }
 
-func (st *postgresStorage) AddCrypto(symbol, name string, price float64, t time.Time) error {
+func (st *postgresStorage) AddCrypto(symbol, name, currency string, price float64, t time.Time) error {
 	st.mu.Lock()
 	defer st.mu.Unlock()
 	var cryptoID int
@@ -241,8 +205,8 @@ func (st *postgresStorage) AddCrypto(symbol, name string, price float64, t time.
 	}
 
 	_, err := st.db.Exec(
-		`INSERT INTO crypto_prices (crypto_id, price, timestamp) VALUES ($1, $2, $3)`,
-		cryptoID, price, t,
+		`INSERT INTO crypto_prices (crypto_id, price, currency, timestamp) VALUES ($1, $2, $3, $4)`,
+		cryptoID, price, currency, t,
 	)
 	if err != nil {
 		return err
@@ -251,43 +215,84 @@ func (st *postgresStorage) AddCrypto(symbol, name string, price float64, t time.
 	return nil
 }
 
-func (st *postgresStorage) GetCrypto(symbol string) ([]storage.CryptoVal, error) {
+func (st *postgresStorage) GetCrypto(symbol, currency string, query storage.HistoryQuery) (storage.HistoryPage, error) {
+	after, hasCursor, err := query.After()
+	if err != nil {
+		return storage.HistoryPage{}, err
+	}
 	st.mu.RLock()
-	defer st.mu.RUnlock()
+	id, ok := st.symbToIDmap[symbol]
+	st.mu.RUnlock()
+	if !ok {
+		return storage.HistoryPage{}, storage.NotTracked(symbol)
+	}
+
+	sqlQuery := `SELECT ts, close, (SELECT name FROM crypto_info WHERE crypto_id = $1)
+		FROM ` + series(st.resolution(query.From)) + ` AS s
+		WHERE true`
+	args := []any{id, currency}
+	if !query.From.IsZero() {
+		args = append(args, query.From)
+		sqlQuery += fmt.Sprintf(" AND ts >= $%d", len(args))
+	}
+	if !query.To.IsZero() {
+		args = append(args, query.To)
+		sqlQuery += fmt.Sprintf(" AND ts <= $%d", len(args))
+	}
+	if hasCursor {
+		args = append(args, after)
+		sqlQuery += fmt.Sprintf(" AND ts > $%d", len(args))
+	}
+	sqlQuery += " ORDER BY ts"
+	if query.Limit > 0 {
+		args = append(args, query.Limit+1)
+		sqlQuery += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := st.db.Query(sqlQuery, args...)
+	if err != nil {
+		return storage.HistoryPage{}, err
+	}
+	defer rows.Close()
 	res := make([]storage.CryptoVal, 0)
-	if id, ok := st.symbToIDmap[symbol]; !ok {
-		return nil, fmt.Errorf("symbol %s is not being tracked", symbol)
-	} else {
-		rows, err := st.db.Query(`SELECT  cp.price, cp.timestamp, ci.name FROM crypto_prices as cp JOIN crypto_info as ci USING (crypto_id) WHERE crypto_id = $1`, id)
-		if err != nil {
-			return nil, err
-		}
-		defer rows.Close()
-		for rows.Next() {
-			var value storage.CryptoVal
-			if err := rows.Scan(&value.Price, &value.Time, &value.Name); err != nil {
-				return nil, err
-			}
-			value.Symbol = symbol
-			res = append(res, value)
+	for rows.Next() {
+		var value storage.CryptoVal
+		if err := rows.Scan(&value.Time, &value.Price, &value.Name); err != nil {
+			return storage.HistoryPage{}, err
 		}
+		value.Symbol = symbol
+		value.Currency = currency
+		res = append(res, value)
 	}
-	return res, nil
+	if err := rows.Err(); err != nil {
+		return storage.HistoryPage{}, err
+	}
+
+	page := storage.HistoryPage{Values: res}
+	if query.Limit > 0 && len(res) > query.Limit {
+		page.Values = res[:query.Limit]
+		page.NextCursor = storage.EncodeCursor(page.Values[query.Limit-1].Time)
+	}
+	return page, nil
 }
 
-func (st *postgresStorage) GetLatestCrypto() (map[string]storage.CryptoVal, error) {
+func (st *postgresStorage) GetLatestCrypto(currency string) (map[string]storage.CryptoVal, error) {
 	st.mu.RLock()
 	defer st.mu.RUnlock()
 	res := make(map[string]storage.CryptoVal)
 	for symbol := range st.symbToIDmap {
 		var value storage.CryptoVal
 		value.Symbol = symbol
+		value.Currency = currency
 		err := st.db.QueryRow(`SELECT cp.price, cp.timestamp, ci.name
 			FROM crypto_prices AS cp
 			JOIN crypto_info AS ci USING (crypto_id)
-			WHERE ci.symbol = $1
+			WHERE ci.symbol = $1 AND cp.currency = $2
 			ORDER BY cp.timestamp DESC
-			LIMIT 1;`, symbol).Scan(&value.Price, &value.Time, &value.Name)
+			LIMIT 1;`, symbol, currency).Scan(&value.Price, &value.Time, &value.Name)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -297,58 +302,70 @@ func (st *postgresStorage) GetLatestCrypto() (map[string]storage.CryptoVal, erro
 }
 
 func (st *postgresStorage) DeleteCrypto(symbol string) error {
-	_, err := st.db.Exec(`DELETE FROM crypto_prices cp
-		USING crypto_info ci
-		WHERE cp.crypto_id = ci.crypto_id
-		  AND ci.symbol = $1;`, symbol)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	id, ok := st.symbToIDmap[symbol]
+	if !ok {
+		return storage.ErrCryptoNotExists
+	}
+	tx, err := st.db.Begin()
 	if err != nil {
 		return err
 	}
-	_, err = st.db.Exec(`DELETE FROM crypto_info WHERE symbol = $1`, symbol)
-	if err != nil {
+	defer tx.Rollback()
+	for _, query := range []string{
+		`DELETE FROM crypto_rollups WHERE crypto_id = $1`,
+		`DELETE FROM crypto_prices WHERE crypto_id = $1`,
+		`DELETE FROM crypto_info WHERE crypto_id = $1`,
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
 		return err
 	}
 	delete(st.symbToIDmap, symbol)
 	return nil
 }
 
-func (st *postgresStorage) GetCryptoStats(symbol string) (storage.CryptoStat, error) {
-	res, err := st.GetCrypto(symbol)
-	if err != nil {
-		return storage.CryptoStat{}, err
-	}
-	if len(res) == 0 {
-		return storage.CryptoStat{}, fmt.Errorf("no records for %s", symbol)
+func (st *postgresStorage) GetCryptoStats(symbol, currency string, from, to time.Time) (storage.CryptoStat, error) {
+	st.mu.RLock()
+	id, ok := st.symbToIDmap[symbol]
+	st.mu.RUnlock()
+	if !ok {
+		return storage.CryptoStat{}, storage.NotTracked(symbol)
 	}
 
-	max := 0.0
-	min := math.Inf(1)
-	sum := 0.0
-
-	for _, v := range res {
-		if v.Price < min {
-			min = v.Price
-		}
-		if v.Price > max {
-			max = v.Price
-		}
-		sum += v.Price
+	args := []any{id, currency}
+	filter := ""
+	if !from.IsZero() {
+		args = append(args, from)
+		filter += fmt.Sprintf(" AND ts >= $%d", len(args))
 	}
-
-	last := res[len(res)-1].Price
-	avg := sum / float64(len(res))
-	priceChange := last - min
-	priceChangePercent := 0.0
-	if min != 0 {
-		priceChangePercent = (priceChange / min) * 100
+	if !to.IsZero() {
+		args = append(args, to)
+		filter += fmt.Sprintf(" AND ts <= $%d", len(args))
 	}
 
-	return storage.CryptoStat{
-		MinPrice:           min,
-		MaxPrice:           max,
-		AvgPrice:           avg,
-		PriceChange:        priceChange,
-		PriceChangePercent: priceChangePercent,
-		RecordsCount:       len(res),
-	}, nil
+	var count int
+	var min, max, avg, first, last sql.NullFloat64
+	err := st.db.QueryRow(`SELECT COALESCE(sum(samples), 0), min(low), max(high),
+	       sum(avg_price * samples) / NULLIF(sum(samples), 0),
+	       min(first_price), min(last_price)
+	FROM (
+		SELECT low, high, avg_price, samples,
+		       first_value(open) OVER w AS first_price,
+		       last_value(close) OVER w AS last_price
+		FROM `+series(st.resolution(from))+` AS s
+		WHERE true`+filter+`
+		WINDOW w AS (ORDER BY ts ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING)
+	) AS windowed`, args...).Scan(&count, &min, &max, &avg, &first, &last)
+	if err != nil {
+		return storage.CryptoStat{}, err
+	}
+	if count == 0 {
+		return storage.CryptoStat{}, storage.NoRecords(symbol)
+	}
+	return storage.NewCryptoStat(min.Float64, max.Float64, avg.Float64, first.Float64, last.Float64, count), nil
 }
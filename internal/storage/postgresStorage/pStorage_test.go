@@ -1,25 +1,172 @@
 package postgresStorage
 
 import (
-	"github.com/zenrot/CryptoService/internal/config/configYaml"
+	"fmt"
+	"math"
+	"os"
 	"testing"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/storage"
+	"github.com/zenrot/CryptoService/internal/storage/storagetest"
 )
 
+// testDSNEnv names the connection string of a disposable database. The suite
+// truncates every table, so it never reads the application's own settings.
+const testDSNEnv = "CRYPTOSERVICE_TEST_POSTGRES_DSN"
+
+func testConfig(tb testing.TB) *config.Config {
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		tb.Skip(testDSNEnv + " is not set")
+	}
+	return &config.Config{PostgresConfig: config.PostgresConfig{DSN: dsn}}
+}
+
 func TestNewPostgresStorage(t *testing.T) {
-	_, err := NewPostgresStorage(&configYaml.Config{
-		CoingeckoKey: "",
-		StorageType:  "",
-		HttpConfig:   configYaml.HttpConfig{},
-		PostgresConfig: configYaml.PostgresConfig{
-			Host:     "localhost",
-			Port:     "5432",
-			User:     "alexey",
-			Password: "",
-			Dbname:   "postgres",
-		},
-	})
+	st, err := NewPostgresStorage(testConfig(t))
+	if err != nil {
+		t.Fatal("NewPostgresStorage err:", err)
+	}
+	st.Close()
+}
+
+// emptyStorage connects to the test database and removes all data so that
+// storagetest cases start from a clean state.
+func emptyStorage(t *testing.T) *postgresStorage {
+	st, err := NewPostgresStorage(testConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = st.db.Exec(`TRUNCATE users, alert_events, alert_rules, webhook_deliveries, webhooks, watchlists, tracked_coins, updater_settings, crypto_rollups, crypto_prices, crypto_info RESTART IDENTITY`)
+	if err != nil {
+		st.Close()
+		t.Fatal(err)
+	}
+	st.symbToIDmap = make(map[string]int)
+	return st
+}
+
+func TestAuthContract(t *testing.T) {
+	storagetest.TestAuth(t, func(t *testing.T) storage.Auth { return emptyStorage(t) })
+}
+
+func TestCryptoContract(t *testing.T) {
+	storagetest.TestCrypto(t, func(t *testing.T) storage.Crypto { return emptyStorage(t) })
+}
+
+func TestAlertsContract(t *testing.T) {
+	storagetest.TestAlerts(t, func(t *testing.T) storage.Alerts { return emptyStorage(t) })
+}
+
+func TestWebhooksContract(t *testing.T) {
+	storagetest.TestWebhooks(t, func(t *testing.T) storage.Webhooks { return emptyStorage(t) })
+}
+
+func TestWatchlistsContract(t *testing.T) {
+	storagetest.TestWatchlists(t, func(t *testing.T) storage.Watchlists { return emptyStorage(t) })
+}
+
+func seedHistory(b *testing.B, st *postgresStorage, symbol string, samples int) time.Time {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := st.AddCrypto(symbol, symbol, "usd", 1, start); err != nil {
+		b.Fatal(err)
+	}
+	_, err := st.db.Exec(`INSERT INTO crypto_prices (crypto_id, price, currency, timestamp)
+		SELECT $1, 100 + random() * 10, 'usd', $2::timestamp + g * interval '3 seconds'
+		FROM generate_series(1, $3) AS g`, st.symbToIDmap[symbol], start, samples-1)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return start
+}
+
+func statsInGo(values []storage.CryptoVal) storage.CryptoStat {
+	max, min, sum := 0.0, math.Inf(1), 0.0
+	for _, v := range values {
+		min = math.Min(min, v.Price)
+		max = math.Max(max, v.Price)
+		sum += v.Price
+	}
+	return storage.NewCryptoStat(min, max, sum/float64(len(values)),
+		values[0].Price, values[len(values)-1].Price, len(values))
+}
+
+func BenchmarkGetCryptoStats(b *testing.B) {
+	st, err := NewPostgresStorage(testConfig(b))
 	if err != nil {
-		t.Error("NewPostgresStorage err:", err)
+		b.Fatal(err)
+	}
+	defer st.Close()
+
+	for _, samples := range []int{1000, 100000} {
+		symbol := fmt.Sprintf("bench-%d-%d", samples, time.Now().UnixNano())
+		start := seedHistory(b, st, symbol, samples)
+		window := start.Add(time.Duration(samples) * time.Second)
+
+		b.Run(fmt.Sprintf("sql/%d", samples), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := st.GetCryptoStats(symbol, "usd", time.Time{}, time.Time{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("sql-window/%d", samples), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := st.GetCryptoStats(symbol, "usd", start, window); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("go/%d", samples), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				page, err := st.GetCrypto(symbol, "usd", storage.HistoryQuery{})
+				if err != nil {
+					b.Fatal(err)
+				}
+				statsInGo(page.Values)
+			}
+		})
+
+		if err := st.DeleteCrypto(symbol); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestConnString(t *testing.T) {
+	tests := []struct {
+		cfg  config.PostgresConfig
+		want string
+	}{
+		{
+			cfg:  config.PostgresConfig{Host: "localhost", Port: "5432", User: "alexey", Dbname: "crypto_service"},
+			want: "host=localhost port=5432 user=alexey dbname=crypto_service sslmode=disable",
+		},
+		{
+			cfg: config.PostgresConfig{
+				Host: "db", User: "svc", Password: "it's secret", Dbname: "crypto",
+				SSLMode: "verify-full", SSLRootCert: "/etc/ssl/ca.pem", StatementTimeout: 5 * time.Second,
+			},
+			want: `host=db user=svc password='it\'s secret' dbname=crypto sslmode=verify-full sslrootcert=/etc/ssl/ca.pem statement_timeout=5000`,
+		},
+		{
+			cfg:  config.PostgresConfig{DSN: "postgres://svc:pw@db:5433/crypto?sslmode=require"},
+			want: "dbname='crypto' host='db' password='pw' port='5433' sslmode='require' user='svc'",
+		},
+		{
+			cfg:  config.PostgresConfig{DSN: "host=db sslmode=require", Dbname: "other"},
+			want: "host=db sslmode=require dbname=other",
+		},
+	}
+	for _, tt := range tests {
+		got, err := connString(&tt.cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("connString(%+v) = %q, want %q", tt.cfg, got, tt.want)
+		}
 	}
-	return
 }
@@ -0,0 +1,148 @@
+package postgresStorage
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	resolutionRaw    = "raw"
+	resolutionHourly = "1h"
+	resolutionDaily  = "1d"
+)
+
+const rawSeries = `SELECT timestamp AS ts, price AS open, price AS high, price AS low, price AS close,
+		price AS avg_price, 1 AS samples
+	FROM crypto_prices WHERE crypto_id = $1 AND currency = $2`
+
+const (
+	hourlyWatermark = `COALESCE((SELECT max(bucket) + interval '1 hour' FROM crypto_rollups
+		WHERE crypto_id = $1 AND currency = $2 AND resolution = '1h'), '-infinity'::timestamp)`
+	dailyWatermark = `COALESCE((SELECT max(bucket) + interval '1 day' FROM crypto_rollups
+		WHERE crypto_id = $1 AND currency = $2 AND resolution = '1d'), '-infinity'::timestamp)`
+	rollupSeries = `SELECT bucket AS ts, open, high, low, close, avg_price, sample_count AS samples
+	FROM crypto_rollups WHERE crypto_id = $1 AND currency = $2`
+)
+
+// series returns a subquery over ($1 crypto_id, $2 currency) with columns
+// ts, open, high, low, close, avg_price, samples. Coarser resolutions read
+// rollups for compacted buckets and finer data for the not yet rolled up tail.
+func series(resolution string) string {
+	switch resolution {
+	case resolutionHourly:
+		return `(` + rollupSeries + ` AND resolution = '1h' AND bucket < ` + hourlyWatermark + `
+	UNION ALL
+	` + rawSeries + ` AND timestamp >= ` + hourlyWatermark + `)`
+	case resolutionDaily:
+		return `(` + rollupSeries + ` AND resolution = '1d' AND bucket < ` + dailyWatermark + `
+	UNION ALL
+	` + rollupSeries + ` AND resolution = '1h' AND bucket >= ` + dailyWatermark + ` AND bucket < ` + hourlyWatermark + `
+	UNION ALL
+	` + rawSeries + ` AND timestamp >= ` + hourlyWatermark + `)`
+	}
+	return `(` + rawSeries + `)`
+}
+
+// resolution picks the finest resolution that still holds data for a range
+// starting at from; a zero from means the whole history.
+func (st *postgresStorage) resolution(from time.Time) string {
+	rc := st.retention
+	if !rc.Enabled || rc.RawRetention <= 0 {
+		return resolutionRaw
+	}
+	now := time.Now()
+	if !from.IsZero() && !from.Before(now.Add(-rc.RawRetention)) {
+		return resolutionRaw
+	}
+	if rc.HourlyRetention <= 0 || (!from.IsZero() && !from.Before(now.Add(-rc.HourlyRetention))) {
+		return resolutionHourly
+	}
+	return resolutionDaily
+}
+
+func (st *postgresStorage) compactor() {
+	defer close(st.compactorDone)
+	ticker := time.NewTicker(st.retention.CompactionInterval)
+	defer ticker.Stop()
+	for {
+		if err := st.Compact(context.Background()); err != nil {
+			log.Println("compaction:", err)
+		}
+		select {
+		case <-st.stopCompactor:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Compact rolls complete hours and days up and removes samples and rollups
+// older than their retention.
+func (st *postgresStorage) Compact(ctx context.Context) error {
+	now := time.Now()
+	tx, err := st.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO crypto_rollups
+		(crypto_id, currency, resolution, bucket, open, high, low, close, avg_price, sample_count)
+	SELECT crypto_id, currency, '1h', date_trunc('hour', timestamp),
+	       (array_agg(price ORDER BY timestamp))[1], max(price), min(price),
+	       (array_agg(price ORDER BY timestamp DESC))[1], avg(price), count(*)
+	FROM crypto_prices AS p
+	WHERE timestamp < date_trunc('hour', $1::timestamp)
+	  AND timestamp >= COALESCE((SELECT max(r.bucket) + interval '1 hour' FROM crypto_rollups AS r
+		WHERE r.crypto_id = p.crypto_id AND r.currency = p.currency AND r.resolution = '1h'),
+		'-infinity'::timestamp)
+	GROUP BY crypto_id, currency, date_trunc('hour', timestamp)
+	ON CONFLICT DO NOTHING`, now)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO crypto_rollups
+		(crypto_id, currency, resolution, bucket, open, high, low, close, avg_price, sample_count)
+	SELECT crypto_id, currency, '1d', date_trunc('day', bucket),
+	       (array_agg(open ORDER BY bucket))[1], max(high), min(low),
+	       (array_agg(close ORDER BY bucket DESC))[1],
+	       sum(avg_price * sample_count) / sum(sample_count), sum(sample_count)
+	FROM crypto_rollups AS h
+	WHERE resolution = '1h'
+	  AND bucket < date_trunc('day', $1::timestamp)
+	  AND bucket >= COALESCE((SELECT max(d.bucket) + interval '1 day' FROM crypto_rollups AS d
+		WHERE d.crypto_id = h.crypto_id AND d.currency = h.currency AND d.resolution = '1d'),
+		'-infinity'::timestamp)
+	GROUP BY crypto_id, currency, date_trunc('day', bucket)
+	ON CONFLICT DO NOTHING`, now)
+	if err != nil {
+		return err
+	}
+
+	rc := st.retention
+	if rc.RawRetention > 0 {
+		_, err = tx.ExecContext(ctx, `DELETE FROM crypto_prices
+		WHERE timestamp < $2::timestamp AND timestamp < date_trunc('hour', $1::timestamp)`,
+			now, now.Add(-rc.RawRetention))
+		if err != nil {
+			return err
+		}
+	}
+	if rc.HourlyRetention > 0 {
+		_, err = tx.ExecContext(ctx, `DELETE FROM crypto_rollups
+		WHERE resolution = '1h' AND bucket < $2::timestamp AND bucket < date_trunc('day', $1::timestamp)`,
+			now, now.Add(-rc.HourlyRetention))
+		if err != nil {
+			return err
+		}
+	}
+	if rc.DailyRetention > 0 {
+		_, err = tx.ExecContext(ctx, `DELETE FROM crypto_rollups
+		WHERE resolution = '1d' AND bucket < $1::timestamp`, now.Add(-rc.DailyRetention))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
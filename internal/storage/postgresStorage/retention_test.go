@@ -0,0 +1,31 @@
+package postgresStorage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+)
+
+func TestResolution(t *testing.T) {
+	policy := config.RetentionConfig{Enabled: true, RawRetention: 7 * 24 * time.Hour, HourlyRetention: 90 * 24 * time.Hour}
+	now := time.Now()
+	tests := []struct {
+		retention config.RetentionConfig
+		from      time.Time
+		want      string
+	}{
+		{config.RetentionConfig{}, time.Time{}, resolutionRaw},
+		{policy, now.Add(-time.Hour), resolutionRaw},
+		{policy, now.Add(-30 * 24 * time.Hour), resolutionHourly},
+		{policy, now.Add(-365 * 24 * time.Hour), resolutionDaily},
+		{policy, time.Time{}, resolutionDaily},
+		{config.RetentionConfig{Enabled: true, RawRetention: time.Hour}, time.Time{}, resolutionHourly},
+	}
+	for _, tt := range tests {
+		st := &postgresStorage{retention: tt.retention}
+		if got := st.resolution(tt.from); got != tt.want {
+			t.Errorf("resolution(%v) with %+v = %s, want %s", tt.from, tt.retention, got, tt.want)
+		}
+	}
+}
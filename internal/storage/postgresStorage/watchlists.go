@@ -0,0 +1,49 @@
+package postgresStorage
+
+import (
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func (st *postgresStorage) SaveWatchlist(list storage.Watchlist) error {
+	_, err := st.db.Exec(`INSERT INTO watchlists (watchlist_id, user_name, name, symbols, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (watchlist_id) DO UPDATE
+		SET name = EXCLUDED.name, symbols = EXCLUDED.symbols, updated_at = EXCLUDED.updated_at`,
+		list.ID, list.User, list.Name, joinList(list.Symbols), list.CreatedAt, list.UpdatedAt)
+	return err
+}
+
+func (st *postgresStorage) DeleteWatchlist(user, id string) error {
+	res, err := st.db.Exec(`DELETE FROM watchlists WHERE watchlist_id = $1 AND user_name = $2`, id, user)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return storage.ErrWatchlistNotExists
+	}
+	return nil
+}
+
+func (st *postgresStorage) GetWatchlists(user string) ([]storage.Watchlist, error) {
+	rows, err := st.db.Query(`SELECT watchlist_id, user_name, name, symbols, created_at, updated_at
+		FROM watchlists
+		WHERE $1 = '' OR user_name = $1
+		ORDER BY created_at, watchlist_id`, user)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]storage.Watchlist, 0)
+	for rows.Next() {
+		var list storage.Watchlist
+		var symbols string
+		if err := rows.Scan(&list.ID, &list.User, &list.Name, &symbols, &list.CreatedAt, &list.UpdatedAt); err != nil {
+			return nil, err
+		}
+		list.Symbols = splitList(symbols)
+		res = append(res, list)
+	}
+	return res, rows.Err()
+}
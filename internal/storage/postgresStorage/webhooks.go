@@ -0,0 +1,138 @@
+package postgresStorage
+
+import (
+	"strings"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func (st *postgresStorage) SaveWebhook(hook storage.Webhook) error {
+	_, err := st.db.Exec(`INSERT INTO webhooks (webhook_id, user_name, url, secret, events, symbols, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (webhook_id) DO UPDATE
+		SET url = EXCLUDED.url, secret = EXCLUDED.secret, events = EXCLUDED.events, symbols = EXCLUDED.symbols`,
+		hook.ID, hook.User, hook.URL, hook.Secret, joinList(hook.Events), joinList(hook.Symbols), hook.CreatedAt)
+	return err
+}
+
+func (st *postgresStorage) DeleteWebhook(user, id string) error {
+	res, err := st.db.Exec(`DELETE FROM webhooks WHERE webhook_id = $1 AND user_name = $2`, id, user)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return storage.ErrWebhookNotExists
+	}
+	return nil
+}
+
+func (st *postgresStorage) GetWebhooks(user string) ([]storage.Webhook, error) {
+	rows, err := st.db.Query(`SELECT webhook_id, user_name, url, secret, events, symbols, created_at
+		FROM webhooks
+		WHERE $1 = '' OR user_name = $1
+		ORDER BY created_at, webhook_id`, user)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]storage.Webhook, 0)
+	for rows.Next() {
+		var hook storage.Webhook
+		var events, symbols string
+		if err := rows.Scan(&hook.ID, &hook.User, &hook.URL, &hook.Secret, &events, &symbols, &hook.CreatedAt); err != nil {
+			return nil, err
+		}
+		hook.Events = splitList(events)
+		hook.Symbols = splitList(symbols)
+		res = append(res, hook)
+	}
+	return res, rows.Err()
+}
+
+func (st *postgresStorage) SaveDelivery(delivery storage.WebhookDelivery) error {
+	res, err := st.db.Exec(`INSERT INTO webhook_deliveries
+		(delivery_id, webhook_id, event, payload, status, attempts, next_attempt, last_error, response_code, created_at, updated_at)
+		SELECT $1::text, webhook_id, $3::text, $4::text, $5::text, $6::int, $7::timestamptz, $8::text, $9::int,
+		       $10::timestamptz, $11::timestamptz
+		FROM webhooks WHERE webhook_id = $2
+		ON CONFLICT (delivery_id) DO UPDATE
+		SET status = EXCLUDED.status, attempts = EXCLUDED.attempts, next_attempt = EXCLUDED.next_attempt,
+		    last_error = EXCLUDED.last_error, response_code = EXCLUDED.response_code, updated_at = EXCLUDED.updated_at`,
+		delivery.ID, delivery.WebhookID, delivery.Event, delivery.Payload, delivery.Status, delivery.Attempts,
+		delivery.NextAttempt, delivery.LastError, delivery.ResponseCode, delivery.CreatedAt, delivery.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return storage.ErrWebhookNotExists
+	}
+	return nil
+}
+
+const deliveryColumns = `delivery_id, webhook_id, event, payload, status, attempts, next_attempt,
+		       last_error, response_code, created_at, updated_at`
+
+func (st *postgresStorage) GetDueDeliveries(before time.Time, limit int) ([]storage.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE status = 'pending' AND next_attempt <= $1
+		ORDER BY next_attempt, delivery_id`
+	args := []any{before}
+	if limit > 0 {
+		query += " LIMIT $2"
+		args = append(args, limit)
+	}
+	return st.queryDeliveries(query, args...)
+}
+
+func (st *postgresStorage) GetDeliveries(webhookID, status string, limit int) ([]storage.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE webhook_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC, delivery_id DESC`
+	args := []any{webhookID, status}
+	if limit > 0 {
+		query += " LIMIT $3"
+		args = append(args, limit)
+	}
+	return st.queryDeliveries(query, args...)
+}
+
+func (st *postgresStorage) queryDeliveries(query string, args ...any) ([]storage.WebhookDelivery, error) {
+	rows, err := st.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]storage.WebhookDelivery, 0)
+	for rows.Next() {
+		var d storage.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.Status, &d.Attempts, &d.NextAttempt,
+			&d.LastError, &d.ResponseCode, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+	return res, rows.Err()
+}
+
+func (st *postgresStorage) PruneDeliveries(before time.Time) error {
+	_, err := st.db.Exec(`DELETE FROM webhook_deliveries WHERE status <> 'pending' AND updated_at < $1`, before)
+	return err
+}
+
+func joinList(values []string) string {
+	return strings.Join(values, ",")
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,74 @@
+package ramstore
+
+import (
+	"sort"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+// maxAlertEvents bounds the history kept per rule; older events are dropped.
+const maxAlertEvents = 1000
+
+func (rs *ramStorage) SaveAlertRule(rule storage.AlertRule) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.commit(walRecord{Op: opSaveAlertRule, Alert: &rule})
+}
+
+func (rs *ramStorage) DeleteAlertRule(user, id string) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rule, ok := rs.alertRules[id]; !ok || rule.User != user {
+		return storage.ErrAlertNotExists
+	}
+	return rs.commit(walRecord{Op: opDeleteAlertRule, Key: id})
+}
+
+func (rs *ramStorage) GetAlertRules(user string) ([]storage.AlertRule, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	res := make([]storage.AlertRule, 0)
+	for _, rule := range rs.alertRules {
+		if user == "" || rule.User == user {
+			res = append(res, rule)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].CreatedAt.Equal(res[j].CreatedAt) {
+			return res[i].CreatedAt.Before(res[j].CreatedAt)
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res, nil
+}
+
+func (rs *ramStorage) AddAlertEvent(event storage.AlertEvent) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if _, ok := rs.alertRules[event.RuleID]; !ok {
+		return storage.ErrAlertNotExists
+	}
+	return rs.commit(walRecord{Op: opAddAlertEvent, Event: &event})
+}
+
+func (rs *ramStorage) GetAlertEvents(ruleID string, limit int) ([]storage.AlertEvent, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	events := rs.alertEvents[ruleID]
+	if limit <= 0 || limit > len(events) {
+		limit = len(events)
+	}
+	res := make([]storage.AlertEvent, 0, limit)
+	for i := len(events) - 1; i >= len(events)-limit; i-- {
+		res = append(res, events[i])
+	}
+	return res, nil
+}
+
+func (rs *ramStorage) addAlertEvent(event storage.AlertEvent) {
+	events := append(rs.alertEvents[event.RuleID], event)
+	if len(events) > maxAlertEvents {
+		events = append([]storage.AlertEvent(nil), events[len(events)-maxAlertEvents:]...)
+	}
+	rs.alertEvents[event.RuleID] = events
+}
@@ -0,0 +1,36 @@
+package ramstore
+
+import (
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func (rs *ramStorage) GetCandles(symbol, currency string, interval time.Duration, from, to time.Time) ([]storage.Candle, error) {
+	page, err := rs.GetCrypto(symbol, currency, storage.HistoryQuery{From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]storage.Candle, 0)
+	for _, v := range page.Values {
+		bucket := v.Time.UTC().Truncate(interval)
+		if n := len(res); n > 0 && res[n-1].Time.Equal(bucket) {
+			c := &res[n-1]
+			c.High = max(c.High, v.Price)
+			c.Low = min(c.Low, v.Price)
+			c.Close = v.Price
+			c.SampleCount++
+			continue
+		}
+		res = append(res, storage.Candle{
+			Time:        bucket,
+			Open:        v.Price,
+			High:        v.Price,
+			Low:         v.Price,
+			Close:       v.Price,
+			SampleCount: 1,
+		})
+	}
+	return res, nil
+}
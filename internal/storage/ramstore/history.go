@@ -0,0 +1,114 @@
+package ramstore
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func (rs *ramStorage) configureHistory(cfg config.RamStorageConfig) {
+	rs.historySize = cfg.HistorySize
+	if rs.historySize <= 0 {
+		rs.historySize = defaultHistorySize
+	}
+	rs.symbolHistorySize = make(map[string]int, len(cfg.SymbolHistorySize))
+	for symbol, size := range cfg.SymbolHistorySize {
+		if size > 0 {
+			rs.symbolHistorySize[strings.ToLower(symbol)] = size
+		}
+	}
+	rs.maxAge = cfg.MaxAge
+}
+
+func (rs *ramStorage) capacity(symbol string) int {
+	if size, ok := rs.symbolHistorySize[symbol]; ok {
+		return size
+	}
+	return rs.historySize
+}
+
+// setHistorySize changes how many samples are kept per currency for symbol,
+// resizing existing history in place. A size <= 0 restores the default.
+func (rs *ramStorage) setHistorySize(symbol string, size int) {
+	symbol = strings.ToLower(symbol)
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if size <= 0 {
+		delete(rs.symbolHistorySize, symbol)
+	} else {
+		rs.symbolHistorySize[symbol] = size
+	}
+	for _, buf := range rs.cryptoData[symbol] {
+		buf.Resize(rs.capacity(symbol))
+	}
+}
+
+func (rs *ramStorage) evictor() {
+	defer close(rs.evictionDone)
+	interval := min(max(rs.maxAge/10, time.Second), time.Minute)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rs.stopEviction:
+			return
+		case <-ticker.C:
+			rs.evictExpired()
+		}
+	}
+}
+
+func (rs *ramStorage) evictExpired() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	cutoff := time.Now().Add(-rs.maxAge)
+	for _, currencies := range rs.cryptoData {
+		for _, buf := range currencies {
+			buf.EvictBefore(cutoff)
+		}
+	}
+}
+
+// MemoryUsage reports approximate memory held by price history: the
+// preallocated buffers plus the strings of stored samples.
+func (rs *ramStorage) MemoryUsage() storage.MemoryUsage {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	usage := storage.MemoryUsage{Series: make([]storage.SeriesUsage, 0)}
+	if rs.maxAge > 0 {
+		usage.MaxAge = rs.maxAge.String()
+	}
+	valSize := int64(unsafe.Sizeof(storage.CryptoVal{}))
+	for symbol, currencies := range rs.cryptoData {
+		for currency, buf := range currencies {
+			series := storage.SeriesUsage{
+				Symbol:   symbol,
+				Currency: currency,
+				Samples:  buf.Len(),
+				Capacity: buf.Cap(),
+				Bytes:    int64(buf.Cap()) * valSize,
+			}
+			for i, v := range buf.Values() {
+				if i == 0 {
+					series.Oldest = v.Time
+				}
+				series.Bytes += int64(len(v.Symbol) + len(v.Name) + len(v.Currency))
+			}
+			usage.Bytes += series.Bytes
+			usage.Samples += series.Samples
+			usage.Capacity += series.Capacity
+			usage.Series = append(usage.Series, series)
+		}
+	}
+	sort.Slice(usage.Series, func(i, j int) bool {
+		if usage.Series[i].Symbol != usage.Series[j].Symbol {
+			return usage.Series[i].Symbol < usage.Series[j].Symbol
+		}
+		return usage.Series[i].Currency < usage.Series[j].Currency
+	})
+	return usage
+}
@@ -1,28 +1,141 @@
 package ramstore
 
 import (
-	"fmt"
+	"github.com/zenrot/CryptoService/internal/config"
 	"github.com/zenrot/CryptoService/internal/crypt"
 	"github.com/zenrot/CryptoService/internal/storage"
 	"github.com/zenrot/CryptoService/internal/storage/ramstore/ringBuffer"
 	"math"
+	"sort"
 	"sync"
 	"time"
 )
 
 type ramStorage struct {
-	userData   map[string]storage.User
-	cryptoData map[string]*ringBuffer.RingBuffer
-	mu         sync.RWMutex
+	userData        map[string]storage.User
+	cryptoData      map[string]map[string]*ringBuffer.RingBuffer
+	trackedCoins    map[string]storage.TrackedCoin
+	defaultSchedule *storage.ScheduleSettings
+	alertRules      map[string]storage.AlertRule
+	alertEvents     map[string][]storage.AlertEvent
+	webhooks        map[string]storage.Webhook
+	deliveries      map[string]storage.WebhookDelivery
+	watchlists      map[string]storage.Watchlist
+	mu              sync.RWMutex
+
+	historySize       int
+	symbolHistorySize map[string]int
+	maxAge            time.Duration
+	stopEviction      chan struct{}
+	evictionDone      chan struct{}
+
+	dataDir       string
+	wal           *wal
+	seq           uint64
+	stopSnapshots chan struct{}
+	snapshotsDone chan struct{}
+}
+
+const defaultHistorySize = 100
+
+func NewRamStorage(cfg *config.Config) (*ramStorage, error) {
+	rs := &ramStorage{
+		userData:     make(map[string]storage.User),
+		cryptoData:   make(map[string]map[string]*ringBuffer.RingBuffer),
+		trackedCoins: make(map[string]storage.TrackedCoin),
+		alertRules:   make(map[string]storage.AlertRule),
+		alertEvents:  make(map[string][]storage.AlertEvent),
+		webhooks:     make(map[string]storage.Webhook),
+		deliveries:   make(map[string]storage.WebhookDelivery),
+		watchlists:   make(map[string]storage.Watchlist),
+	}
+	rs.configureHistory(cfg.RamStorageConfig)
+	if cfg.DataDir != "" {
+		if err := rs.openDataDir(cfg.RamStorageConfig); err != nil {
+			return nil, err
+		}
+	}
+	if rs.maxAge > 0 {
+		rs.stopEviction = make(chan struct{})
+		rs.evictionDone = make(chan struct{})
+		go rs.evictor()
+	}
+	return rs, nil
+}
+
+func (rs *ramStorage) Close() error {
+	if rs.stopEviction != nil {
+		close(rs.stopEviction)
+		<-rs.evictionDone
+		rs.stopEviction = nil
+	}
+	if rs.stopSnapshots != nil {
+		close(rs.stopSnapshots)
+		<-rs.snapshotsDone
+		rs.stopSnapshots = nil
+	}
+	if rs.wal == nil {
+		return nil
+	}
+	err := rs.Snapshot()
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if closeErr := rs.wal.close(); err == nil {
+		err = closeErr
+	}
+	rs.wal = nil
+	return err
 }
 
-const maxHistory = 100
+// commit appends rec to the WAL when persistence is enabled and applies it.
+// Callers hold rs.mu.
+func (rs *ramStorage) commit(rec walRecord) error {
+	if rs.wal != nil {
+		rec.Seq = rs.seq + 1
+		if err := rs.wal.append(rec); err != nil {
+			return err
+		}
+		rs.seq = rec.Seq
+	}
+	rs.apply(rec)
+	return nil
+}
 
-func NewRamStorage() (*ramStorage, error) {
-	return &ramStorage{
-		userData:   make(map[string]storage.User),
-		cryptoData: make(map[string]*ringBuffer.RingBuffer),
-	}, nil
+func (rs *ramStorage) apply(rec walRecord) {
+	switch rec.Op {
+	case opRegisterUser:
+		rs.userData[rec.User.Name] = *rec.User
+	case opAddCrypto:
+		rs.addCrypto(*rec.Value)
+	case opDeleteCrypto:
+		delete(rs.cryptoData, rec.Key)
+	case opSaveTrackedCoin:
+		rs.trackedCoins[rec.Coin.ID] = *rec.Coin
+	case opDeleteTrackedCoin:
+		delete(rs.trackedCoins, rec.Key)
+	case opSaveSchedule:
+		settings := *rec.Schedule
+		rs.defaultSchedule = &settings
+	case opSaveAlertRule:
+		rs.alertRules[rec.Alert.ID] = *rec.Alert
+	case opDeleteAlertRule:
+		delete(rs.alertRules, rec.Key)
+		delete(rs.alertEvents, rec.Key)
+	case opAddAlertEvent:
+		rs.addAlertEvent(*rec.Event)
+	case opSaveWebhook:
+		rs.webhooks[rec.Webhook.ID] = *rec.Webhook
+	case opDeleteWebhook:
+		rs.deleteWebhook(rec.Key)
+	case opSaveDelivery:
+		rs.deliveries[rec.Delivery.ID] = *rec.Delivery
+	case opPruneDeliveries:
+		rs.pruneDeliveries(*rec.Before)
+	case opSaveWatchlist:
+		rs.watchlists[rec.Watchlist.ID] = *rec.Watchlist
+	case opDeleteWatchlist:
+		delete(rs.watchlists, rec.Key)
+	}
 }
 
 func (rs *ramStorage) RegisterUser(name, password string) error {
@@ -35,8 +148,8 @@ func (rs *ramStorage) RegisterUser(name, password string) error {
 		if err != nil {
 			return err
 		}
-		rs.userData[name] = storage.NewUser(name, hashedPasswd)
-		return nil
+		user := storage.NewUser(name, hashedPasswd)
+		return rs.commit(walRecord{Op: opRegisterUser, User: &user})
 	}
 }
 
@@ -53,36 +166,92 @@ func (rs *ramStorage) LoginUser(name, password string) (*storage.User, error) {
 	return &user, nil
 }
 
-func (rs *ramStorage) AddCrypto(symbol, name string, price float64, time time.Time) error {
+func (rs *ramStorage) AddCrypto(symbol, name, currency string, price float64, time time.Time) error {
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
-	if _, ok := rs.cryptoData[symbol]; !ok {
-		rs.cryptoData[symbol] = ringBuffer.NewRingBuffer(maxHistory)
-	}
-
-	val := storage.NewCryptoVal(symbol, name, price, time)
+	val := storage.NewCryptoVal(symbol, name, currency, price, time)
+	return rs.commit(walRecord{Op: opAddCrypto, Value: &val})
+}
 
-	rs.cryptoData[symbol].Add(val)
-	return nil
+func (rs *ramStorage) addCrypto(val storage.CryptoVal) {
+	if _, ok := rs.cryptoData[val.Symbol]; !ok {
+		rs.cryptoData[val.Symbol] = make(map[string]*ringBuffer.RingBuffer)
+	}
+	buf, ok := rs.cryptoData[val.Symbol][val.Currency]
+	if !ok {
+		buf = ringBuffer.NewRingBuffer(rs.capacity(val.Symbol))
+		rs.cryptoData[val.Symbol][val.Currency] = buf
+	}
+	buf.Add(val)
+	if rs.maxAge > 0 {
+		buf.EvictBefore(time.Now().Add(-rs.maxAge))
+	}
 }
 
-func (rs *ramStorage) GetCrypto(symbol string) ([]storage.CryptoVal, error) {
+func (rs *ramStorage) GetCrypto(symbol, currency string, query storage.HistoryQuery) (storage.HistoryPage, error) {
+	after, hasCursor, err := query.After()
+	if err != nil {
+		return storage.HistoryPage{}, err
+	}
 	rs.mu.RLock()
 	defer rs.mu.RUnlock()
+	values, err := rs.getCrypto(symbol, currency)
+	if err != nil {
+		return storage.HistoryPage{}, err
+	}
+
+	start := 0
+	if !query.From.IsZero() {
+		start = sort.Search(len(values), func(i int) bool {
+			return !values[i].Time.Before(query.From)
+		})
+	}
+	if hasCursor {
+		start = max(start, sort.Search(len(values), func(i int) bool {
+			return values[i].Time.After(after)
+		}))
+	}
+	end := len(values)
+	if !query.To.IsZero() {
+		end = sort.Search(len(values), func(i int) bool {
+			return values[i].Time.After(query.To)
+		})
+	}
+	if start >= end {
+		return storage.HistoryPage{Values: []storage.CryptoVal{}}, nil
+	}
+
+	page := storage.HistoryPage{Values: values[start:end]}
+	if query.Limit > 0 && len(page.Values) > query.Limit {
+		page.Values = page.Values[:query.Limit]
+		page.NextCursor = storage.EncodeCursor(page.Values[query.Limit-1].Time)
+	}
+	return page, nil
+}
+
+func (rs *ramStorage) getCrypto(symbol, currency string) ([]storage.CryptoVal, error) {
 	if _, ok := rs.cryptoData[symbol]; !ok {
-		return nil, fmt.Errorf("symbol %s is not being tracked", symbol)
+		return nil, storage.NotTracked(symbol)
+	}
+	buf, ok := rs.cryptoData[symbol][currency]
+	if !ok {
+		return []storage.CryptoVal{}, nil
 	}
 
-	res := rs.cryptoData[symbol].Values()
+	res := buf.Values()
 	return res, nil
 }
 
-func (rs *ramStorage) GetLatestCrypto() (map[string]storage.CryptoVal, error) {
+func (rs *ramStorage) GetLatestCrypto(currency string) (map[string]storage.CryptoVal, error) {
 	rs.mu.RLock()
 	defer rs.mu.RUnlock()
 	res := make(map[string]storage.CryptoVal)
 	for _, val := range rs.cryptoData {
-		t, ok := val.Last()
+		buf, ok := val[currency]
+		if !ok {
+			continue
+		}
+		t, ok := buf.Last()
 		if !ok {
 			continue
 		}
@@ -97,20 +266,17 @@ func (rs *ramStorage) DeleteCrypto(symbol string) error {
 	if _, ok := rs.cryptoData[symbol]; !ok {
 		return storage.ErrCryptoNotExists
 	}
-	delete(rs.cryptoData, symbol)
-	return nil
+	return rs.commit(walRecord{Op: opDeleteCrypto, Key: symbol})
 }
 
-func (rs *ramStorage) GetCryptoStats(symbol string) (storage.CryptoStat, error) {
-	rs.mu.RLock()
-	defer rs.mu.RUnlock()
-
-	res, err := rs.GetCrypto(symbol)
+func (rs *ramStorage) GetCryptoStats(symbol, currency string, from, to time.Time) (storage.CryptoStat, error) {
+	page, err := rs.GetCrypto(symbol, currency, storage.HistoryQuery{From: from, To: to})
 	if err != nil {
 		return storage.CryptoStat{}, err
 	}
+	res := page.Values
 	if len(res) == 0 {
-		return storage.CryptoStat{}, fmt.Errorf("no records for %s", symbol)
+		return storage.CryptoStat{}, storage.NoRecords(symbol)
 	}
 
 	max := 0.0
@@ -127,20 +293,6 @@ func (rs *ramStorage) GetCryptoStats(symbol string) (storage.CryptoStat, error)
 		sum += v.Price
 	}
 
-	last := res[len(res)-1].Price
 	avg := sum / float64(len(res))
-	priceChange := last - min
-	priceChangePercent := 0.0
-	if min != 0 {
-		priceChangePercent = (priceChange / min) * 100
-	}
-
-	return storage.CryptoStat{
-		MinPrice:           min,
-		MaxPrice:           max,
-		AvgPrice:           avg,
-		PriceChange:        priceChange,
-		PriceChangePercent: priceChangePercent,
-		RecordsCount:       len(res),
-	}, nil
+	return storage.NewCryptoStat(min, max, avg, res[0].Price, res[len(res)-1].Price, len(res)), nil
 }
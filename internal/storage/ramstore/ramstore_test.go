@@ -0,0 +1,172 @@
+package ramstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/storage"
+	"github.com/zenrot/CryptoService/internal/storage/storagetest"
+)
+
+func newTestStorage(t *testing.T) *ramStorage {
+	rs, err := NewRamStorage(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rs
+}
+
+func TestAuthContract(t *testing.T) {
+	storagetest.TestAuth(t, func(t *testing.T) storage.Auth { return newTestStorage(t) })
+}
+
+func TestCryptoContract(t *testing.T) {
+	storagetest.TestCrypto(t, func(t *testing.T) storage.Crypto { return newTestStorage(t) })
+}
+
+func TestAlertsContract(t *testing.T) {
+	storagetest.TestAlerts(t, func(t *testing.T) storage.Alerts { return newTestStorage(t) })
+}
+
+func TestWebhooksContract(t *testing.T) {
+	storagetest.TestWebhooks(t, func(t *testing.T) storage.Webhooks { return newTestStorage(t) })
+}
+
+func TestWatchlistsContract(t *testing.T) {
+	storagetest.TestWatchlists(t, func(t *testing.T) storage.Watchlists { return newTestStorage(t) })
+}
+
+func TestGetCryptoPagination(t *testing.T) {
+	rs, err := NewRamStorage(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		if err := rs.AddCrypto("btc", "Bitcoin", "usd", float64(i), base.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	query := storage.HistoryQuery{
+		From:  base.Add(2 * time.Minute),
+		To:    base.Add(8 * time.Minute),
+		Limit: 3,
+	}
+	var prices []float64
+	pages := 0
+	for {
+		page, err := rs.GetCrypto("btc", "usd", query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		for _, v := range page.Values {
+			prices = append(prices, v.Price)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	if pages != 3 || len(prices) != 7 || prices[0] != 2 || prices[6] != 8 {
+		t.Errorf("pages = %d, prices = %v", pages, prices)
+	}
+
+	page, err := rs.GetCrypto("btc", "usd", storage.HistoryQuery{From: base.Add(time.Hour)})
+	if err != nil || len(page.Values) != 0 || page.NextCursor != "" {
+		t.Errorf("empty range = %+v, %v", page, err)
+	}
+	if _, err := rs.GetCrypto("btc", "usd", storage.HistoryQuery{Cursor: "!!"}); !errors.Is(err, storage.ErrInvalidCursor) {
+		t.Errorf("bad cursor err = %v", err)
+	}
+	if _, err := rs.GetCrypto("eth", "usd", storage.HistoryQuery{}); err == nil {
+		t.Error("expected error for untracked symbol")
+	}
+}
+
+func TestGetCandles(t *testing.T) {
+	rs, err := NewRamStorage(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	prices := []float64{10, 12, 9, 11, 20, 18}
+	for i, price := range prices {
+		if err := rs.AddCrypto("btc", "Bitcoin", "usd", price, base.Add(time.Duration(i)*2*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	candles, err := rs.GetCandles("btc", "usd", 5*time.Minute, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []storage.Candle{
+		{Time: base, Open: 10, High: 12, Low: 9, Close: 9, SampleCount: 3},
+		{Time: base.Add(5 * time.Minute), Open: 11, High: 20, Low: 11, Close: 20, SampleCount: 2},
+		{Time: base.Add(10 * time.Minute), Open: 18, High: 18, Low: 18, Close: 18, SampleCount: 1},
+	}
+	if len(candles) != len(want) {
+		t.Fatalf("candles = %+v", candles)
+	}
+	for i := range want {
+		if candles[i] != want[i] {
+			t.Errorf("candle %d = %+v, want %+v", i, candles[i], want[i])
+		}
+	}
+
+	candles, err = rs.GetCandles("btc", "usd", time.Hour, base.Add(3*time.Minute), base.Add(8*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 1 || candles[0].Open != 9 || candles[0].Close != 20 || candles[0].SampleCount != 3 {
+		t.Errorf("ranged candles = %+v", candles)
+	}
+}
+
+func TestHistoryLimits(t *testing.T) {
+	rs, err := NewRamStorage(&config.Config{RamStorageConfig: config.RamStorageConfig{
+		HistorySize:       3,
+		SymbolHistorySize: map[string]int{"BTC": 5},
+		MaxAge:            time.Hour,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.Close()
+	now := time.Now()
+	for i := 0; i < 8; i++ {
+		ts := now.Add(time.Duration(i-7) * time.Minute)
+		rs.AddCrypto("btc", "Bitcoin", "usd", float64(i), ts)
+		rs.AddCrypto("eth", "Ethereum", "usd", float64(i), ts)
+	}
+	rs.AddCrypto("doge", "Dogecoin", "usd", 1, now.Add(-2*time.Hour))
+
+	count := func(symbol string) int {
+		page, err := rs.GetCrypto(symbol, "usd", storage.HistoryQuery{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(page.Values)
+	}
+	if count("btc") != 5 || count("eth") != 3 {
+		t.Fatalf("per-symbol capacity not applied: btc=%d eth=%d", count("btc"), count("eth"))
+	}
+	if count("doge") != 0 {
+		t.Fatal("sample older than max age was kept")
+	}
+
+	rs.setHistorySize("ETH", 10)
+	rs.AddCrypto("eth", "Ethereum", "usd", 8, now)
+	if count("eth") != 4 {
+		t.Fatalf("resize lost data: eth=%d", count("eth"))
+	}
+
+	usage := rs.MemoryUsage()
+	if usage.Samples != 9 || usage.Capacity != 3+5+10 || usage.Bytes <= 0 || len(usage.Series) != 3 {
+		t.Fatalf("unexpected memory usage: %+v", usage)
+	}
+}
@@ -1,6 +1,11 @@
 package ringBuffer
 
-import "github.com/zenrot/CryptoService/internal/storage"
+import (
+	"sort"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
 
 type RingBuffer struct {
 	data  []storage.CryptoVal
@@ -10,11 +15,17 @@ type RingBuffer struct {
 
 func NewRingBuffer(capacity int) *RingBuffer {
 	return &RingBuffer{
-		data: make([]storage.CryptoVal, capacity),
+		data: make([]storage.CryptoVal, max(capacity, 1)),
 	}
 }
 
+// Add appends val, evicting the oldest value when full. A value older than
+// the last one is inserted in time order.
 func (r *RingBuffer) Add(val storage.CryptoVal) {
+	if last, ok := r.Last(); ok && val.Time.Before(last.Time) {
+		r.insert(val)
+		return
+	}
 	r.data[(r.start+r.size)%len(r.data)] = val
 	if r.size < len(r.data) {
 		r.size++
@@ -23,6 +34,23 @@ func (r *RingBuffer) Add(val storage.CryptoVal) {
 	}
 }
 
+func (r *RingBuffer) insert(val storage.CryptoVal) {
+	values := r.Values()
+	pos := sort.Search(len(values), func(i int) bool {
+		return values[i].Time.After(val.Time)
+	})
+	if pos == 0 && r.size == len(r.data) {
+		return
+	}
+	values = append(values[:pos], append([]storage.CryptoVal{val}, values[pos:]...)...)
+	if len(values) > len(r.data) {
+		values = values[1:]
+	}
+	copy(r.data, values)
+	r.start = 0
+	r.size = len(values)
+}
+
 func (r *RingBuffer) Values() []storage.CryptoVal {
 	res := make([]storage.CryptoVal, r.size)
 	for i := 0; i < r.size; i++ {
@@ -39,3 +67,41 @@ func (r *RingBuffer) Last() (storage.CryptoVal, bool) {
 	idx := (r.start + r.size - 1) % len(r.data)
 	return r.data[idx], true
 }
+
+func (r *RingBuffer) Len() int {
+	return r.size
+}
+
+func (r *RingBuffer) Cap() int {
+	return len(r.data)
+}
+
+// Resize changes the capacity. Growing keeps every value; shrinking keeps
+// the newest ones.
+func (r *RingBuffer) Resize(capacity int) {
+	capacity = max(capacity, 1)
+	if capacity == len(r.data) {
+		return
+	}
+	values := r.Values()
+	if len(values) > capacity {
+		values = values[len(values)-capacity:]
+	}
+	r.data = make([]storage.CryptoVal, capacity)
+	copy(r.data, values)
+	r.start = 0
+	r.size = len(values)
+}
+
+// EvictBefore drops values older than t and returns how many were dropped.
+// Values are expected in time order.
+func (r *RingBuffer) EvictBefore(t time.Time) int {
+	evicted := 0
+	for r.size > 0 && r.data[r.start].Time.Before(t) {
+		r.data[r.start] = storage.CryptoVal{}
+		r.start = (r.start + 1) % len(r.data)
+		r.size--
+		evicted++
+	}
+	return evicted
+}
@@ -0,0 +1,97 @@
+package ringBuffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func fill(r *RingBuffer, base time.Time, n int) {
+	for i := 0; i < n; i++ {
+		r.Add(storage.CryptoVal{Price: float64(i), Time: base.Add(time.Duration(i) * time.Minute)})
+	}
+}
+
+func prices(r *RingBuffer) []float64 {
+	res := make([]float64, 0, r.Len())
+	for _, v := range r.Values() {
+		res = append(res, v.Price)
+	}
+	return res
+}
+
+func equal(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestResize(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewRingBuffer(4)
+	fill(r, base, 6)
+
+	r.Resize(8)
+	if r.Cap() != 8 || !equal(prices(r), []float64{2, 3, 4, 5}) {
+		t.Fatalf("grow lost data: %v", prices(r))
+	}
+	r.Add(storage.CryptoVal{Price: 6, Time: base.Add(6 * time.Minute)})
+	if !equal(prices(r), []float64{2, 3, 4, 5, 6}) {
+		t.Fatalf("unexpected values after grow: %v", prices(r))
+	}
+
+	r.Resize(2)
+	if r.Cap() != 2 || !equal(prices(r), []float64{5, 6}) {
+		t.Fatalf("shrink must keep newest values: %v", prices(r))
+	}
+}
+
+func TestEvictBefore(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewRingBuffer(4)
+	fill(r, base, 6)
+
+	if n := r.EvictBefore(base.Add(4 * time.Minute)); n != 2 {
+		t.Fatalf("evicted %d, want 2", n)
+	}
+	if !equal(prices(r), []float64{4, 5}) {
+		t.Fatalf("unexpected values: %v", prices(r))
+	}
+	if last, ok := r.Last(); !ok || last.Price != 5 {
+		t.Fatalf("unexpected last: %+v", last)
+	}
+	if n := r.EvictBefore(base.Add(time.Hour)); n != 2 || r.Len() != 0 {
+		t.Fatalf("expected empty buffer, evicted %d, len %d", n, r.Len())
+	}
+}
+
+func TestAddOutOfOrder(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewRingBuffer(4)
+	r.Add(storage.CryptoVal{Price: 1, Time: base.Add(time.Minute)})
+	r.Add(storage.CryptoVal{Price: 3, Time: base.Add(3 * time.Minute)})
+	r.Add(storage.CryptoVal{Price: 2, Time: base.Add(2 * time.Minute)})
+	r.Add(storage.CryptoVal{Price: 4, Time: base.Add(4 * time.Minute)})
+	if !equal(prices(r), []float64{1, 2, 3, 4}) {
+		t.Fatalf("values not in time order: %v", prices(r))
+	}
+
+	r.Add(storage.CryptoVal{Price: 2.5, Time: base.Add(150 * time.Second)})
+	if !equal(prices(r), []float64{2, 2.5, 3, 4}) {
+		t.Fatalf("full buffer must drop the oldest value: %v", prices(r))
+	}
+	r.Add(storage.CryptoVal{Price: 0, Time: base})
+	if !equal(prices(r), []float64{2, 2.5, 3, 4}) {
+		t.Fatalf("value older than a full buffer must be dropped: %v", prices(r))
+	}
+	if last, _ := r.Last(); last.Price != 4 {
+		t.Fatalf("unexpected last: %+v", last)
+	}
+}
@@ -0,0 +1,206 @@
+package ramstore
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+const (
+	walFile      = "wal.log"
+	snapshotFile = "snapshot.json"
+)
+
+type snapshot struct {
+	Seq             uint64                    `json:"seq"`
+	Users           []storage.User            `json:"users"`
+	Prices          []storage.CryptoVal       `json:"prices"`
+	TrackedCoins    []storage.TrackedCoin     `json:"tracked_coins"`
+	DefaultSchedule *storage.ScheduleSettings `json:"default_schedule,omitempty"`
+	AlertRules      []storage.AlertRule       `json:"alert_rules"`
+	AlertEvents     []storage.AlertEvent      `json:"alert_events"`
+	Webhooks        []storage.Webhook         `json:"webhooks"`
+	Deliveries      []storage.WebhookDelivery `json:"deliveries"`
+	Watchlists      []storage.Watchlist       `json:"watchlists"`
+}
+
+// openDataDir restores state from the snapshot and the WAL records written
+// after it, then keeps the WAL open for appends.
+func (rs *ramStorage) openDataDir(cfg config.RamStorageConfig) error {
+	if err := os.MkdirAll(cfg.DataDir, 0o700); err != nil {
+		return err
+	}
+	rs.dataDir = cfg.DataDir
+	if err := rs.loadSnapshot(); err != nil {
+		return err
+	}
+	w, err := openWAL(filepath.Join(cfg.DataDir, walFile), cfg.SyncWrites)
+	if err != nil {
+		return err
+	}
+	snapSeq := rs.seq
+	err = w.replay(func(rec walRecord) {
+		if rec.Seq <= snapSeq {
+			return
+		}
+		rs.apply(rec)
+		rs.seq = rec.Seq
+	})
+	if err != nil {
+		w.close()
+		return err
+	}
+	rs.wal = w
+
+	if cfg.SnapshotInterval > 0 {
+		rs.stopSnapshots = make(chan struct{})
+		rs.snapshotsDone = make(chan struct{})
+		go rs.snapshotter(cfg.SnapshotInterval)
+	}
+	return nil
+}
+
+func (rs *ramStorage) loadSnapshot() error {
+	b, err := os.ReadFile(filepath.Join(rs.dataDir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var snap snapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return err
+	}
+	for _, user := range snap.Users {
+		rs.userData[user.Name] = user
+	}
+	for _, val := range snap.Prices {
+		rs.addCrypto(val)
+	}
+	for _, coin := range snap.TrackedCoins {
+		rs.trackedCoins[coin.ID] = coin
+	}
+	rs.defaultSchedule = snap.DefaultSchedule
+	for _, rule := range snap.AlertRules {
+		rs.alertRules[rule.ID] = rule
+	}
+	for _, event := range snap.AlertEvents {
+		rs.addAlertEvent(event)
+	}
+	for _, hook := range snap.Webhooks {
+		rs.webhooks[hook.ID] = hook
+	}
+	for _, delivery := range snap.Deliveries {
+		rs.deliveries[delivery.ID] = delivery
+	}
+	for _, list := range snap.Watchlists {
+		rs.watchlists[list.ID] = list
+	}
+	rs.seq = snap.Seq
+	return nil
+}
+
+func (rs *ramStorage) snapshotter(interval time.Duration) {
+	defer close(rs.snapshotsDone)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rs.stopSnapshots:
+			return
+		case <-ticker.C:
+			if err := rs.Snapshot(); err != nil {
+				log.Println("snapshot:", err)
+			}
+		}
+	}
+}
+
+// Snapshot writes the current state to the data directory and truncates the
+// WAL. It is a no-op when persistence is disabled.
+func (rs *ramStorage) Snapshot() error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.wal == nil {
+		return nil
+	}
+
+	snap := snapshot{
+		Seq:             rs.seq,
+		Users:           make([]storage.User, 0, len(rs.userData)),
+		Prices:          make([]storage.CryptoVal, 0),
+		TrackedCoins:    make([]storage.TrackedCoin, 0, len(rs.trackedCoins)),
+		DefaultSchedule: rs.defaultSchedule,
+		AlertRules:      make([]storage.AlertRule, 0, len(rs.alertRules)),
+		AlertEvents:     make([]storage.AlertEvent, 0),
+		Webhooks:        make([]storage.Webhook, 0, len(rs.webhooks)),
+		Deliveries:      make([]storage.WebhookDelivery, 0, len(rs.deliveries)),
+		Watchlists:      make([]storage.Watchlist, 0, len(rs.watchlists)),
+	}
+	for _, user := range rs.userData {
+		snap.Users = append(snap.Users, user)
+	}
+	for _, currencies := range rs.cryptoData {
+		for _, buf := range currencies {
+			snap.Prices = append(snap.Prices, buf.Values()...)
+		}
+	}
+	for _, coin := range rs.trackedCoins {
+		snap.TrackedCoins = append(snap.TrackedCoins, coin)
+	}
+	for _, rule := range rs.alertRules {
+		snap.AlertRules = append(snap.AlertRules, rule)
+	}
+	for _, events := range rs.alertEvents {
+		snap.AlertEvents = append(snap.AlertEvents, events...)
+	}
+	for _, hook := range rs.webhooks {
+		snap.Webhooks = append(snap.Webhooks, hook)
+	}
+	for _, delivery := range rs.deliveries {
+		snap.Deliveries = append(snap.Deliveries, delivery)
+	}
+	for _, list := range rs.watchlists {
+		snap.Watchlists = append(snap.Watchlists, list)
+	}
+
+	if err := writeFileAtomic(filepath.Join(rs.dataDir, snapshotFile), snap); err != nil {
+		return err
+	}
+	return rs.wal.reset()
+}
+
+func writeFileAtomic(path string, v any) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := json.NewEncoder(tmp).Encode(v); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
@@ -0,0 +1,47 @@
+package ramstore
+
+import (
+	"sort"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func (rs *ramStorage) SaveTrackedCoin(coin storage.TrackedCoin) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.commit(walRecord{Op: opSaveTrackedCoin, Coin: &coin})
+}
+
+func (rs *ramStorage) DeleteTrackedCoin(id string) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.commit(walRecord{Op: opDeleteTrackedCoin, Key: id})
+}
+
+func (rs *ramStorage) GetTrackedCoins() ([]storage.TrackedCoin, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	res := make([]storage.TrackedCoin, 0, len(rs.trackedCoins))
+	for _, coin := range rs.trackedCoins {
+		res = append(res, coin)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res, nil
+}
+
+func (rs *ramStorage) SaveDefaultSchedule(settings storage.ScheduleSettings) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.commit(walRecord{Op: opSaveSchedule, Schedule: &settings})
+}
+
+func (rs *ramStorage) GetDefaultSchedule() (storage.ScheduleSettings, bool, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	if rs.defaultSchedule == nil {
+		return storage.ScheduleSettings{}, false, nil
+	}
+	return *rs.defaultSchedule, true, nil
+}
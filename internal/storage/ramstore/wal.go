@@ -0,0 +1,155 @@
+package ramstore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+const (
+	opRegisterUser      = "register_user"
+	opAddCrypto         = "add_crypto"
+	opDeleteCrypto      = "delete_crypto"
+	opSaveTrackedCoin   = "save_tracked_coin"
+	opDeleteTrackedCoin = "delete_tracked_coin"
+	opSaveSchedule      = "save_schedule"
+	opSaveAlertRule     = "save_alert_rule"
+	opDeleteAlertRule   = "delete_alert_rule"
+	opAddAlertEvent     = "add_alert_event"
+	opSaveWebhook       = "save_webhook"
+	opDeleteWebhook     = "delete_webhook"
+	opSaveDelivery      = "save_delivery"
+	opPruneDeliveries   = "prune_deliveries"
+	opSaveWatchlist     = "save_watchlist"
+	opDeleteWatchlist   = "delete_watchlist"
+)
+
+type walRecord struct {
+	Seq       uint64                    `json:"seq"`
+	Op        string                    `json:"op"`
+	User      *storage.User             `json:"user,omitempty"`
+	Value     *storage.CryptoVal        `json:"value,omitempty"`
+	Key       string                    `json:"key,omitempty"`
+	Coin      *storage.TrackedCoin      `json:"coin,omitempty"`
+	Schedule  *storage.ScheduleSettings `json:"schedule,omitempty"`
+	Alert     *storage.AlertRule        `json:"alert,omitempty"`
+	Event     *storage.AlertEvent       `json:"event,omitempty"`
+	Webhook   *storage.Webhook          `json:"webhook,omitempty"`
+	Delivery  *storage.WebhookDelivery  `json:"delivery,omitempty"`
+	Before    *time.Time                `json:"before,omitempty"`
+	Watchlist *storage.Watchlist        `json:"watchlist,omitempty"`
+}
+
+func (rec walRecord) valid() bool {
+	switch rec.Op {
+	case opRegisterUser:
+		return rec.User != nil
+	case opAddCrypto:
+		return rec.Value != nil
+	case opDeleteCrypto, opDeleteTrackedCoin, opDeleteAlertRule, opDeleteWebhook, opDeleteWatchlist:
+		return rec.Key != ""
+	case opSaveTrackedCoin:
+		return rec.Coin != nil
+	case opSaveSchedule:
+		return rec.Schedule != nil
+	case opSaveAlertRule:
+		return rec.Alert != nil
+	case opAddAlertEvent:
+		return rec.Event != nil
+	case opSaveWebhook:
+		return rec.Webhook != nil
+	case opSaveDelivery:
+		return rec.Delivery != nil
+	case opPruneDeliveries:
+		return rec.Before != nil
+	case opSaveWatchlist:
+		return rec.Watchlist != nil
+	}
+	return false
+}
+
+type wal struct {
+	file *os.File
+	size int64
+	sync bool
+}
+
+func openWAL(path string, sync bool) (*wal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &wal{file: file, size: info.Size(), sync: sync}, nil
+}
+
+// replay calls apply for every record in the log. A torn record at the end
+// (an interrupted write) is cut off; corruption anywhere else is an error.
+func (w *wal) replay(apply func(walRecord)) error {
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(w.file)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(line) == 0 {
+			return nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		var rec walRecord
+		if jsonErr := json.Unmarshal(bytes.TrimSpace(line), &rec); jsonErr != nil || !rec.valid() || errors.Is(err, io.EOF) {
+			if _, peekErr := reader.Peek(1); !errors.Is(peekErr, io.EOF) {
+				return fmt.Errorf("wal corrupted at offset %d", offset)
+			}
+			log.Printf("wal: dropping torn record at offset %d", offset)
+			w.size = offset
+			return w.file.Truncate(offset)
+		}
+		apply(rec)
+		offset += int64(len(line))
+	}
+}
+
+func (w *wal) append(rec walRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if _, err := w.file.Write(b); err != nil {
+		w.file.Truncate(w.size)
+		return err
+	}
+	w.size += int64(len(b))
+	if w.sync {
+		return w.file.Sync()
+	}
+	return nil
+}
+
+func (w *wal) reset() error {
+	if err := w.file.Truncate(0); err != nil {
+		return err
+	}
+	w.size = 0
+	return w.file.Sync()
+}
+
+func (w *wal) close() error {
+	return w.file.Close()
+}
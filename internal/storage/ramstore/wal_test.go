@@ -0,0 +1,127 @@
+package ramstore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func durableConfig(dir string) *config.Config {
+	return &config.Config{RamStorageConfig: config.RamStorageConfig{DataDir: dir}}
+}
+
+func TestDurableRamStorage(t *testing.T) {
+	dir := t.TempDir()
+	rs, err := NewRamStorage(durableConfig(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := rs.RegisterUser("alice", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := rs.AddCrypto("btc", "Bitcoin", "usd", float64(i), base.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rule := storage.AlertRule{ID: "r1", User: "alice", Symbol: "btc", Type: storage.AlertAbove, Threshold: 2, CreatedAt: base}
+	if err := rs.SaveAlertRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.AddAlertEvent(storage.AlertEvent{RuleID: "r1", State: storage.AlertTriggered, Price: 3, Time: base}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.AddCrypto("btc", "Bitcoin", "usd", 3, base.Add(3*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.AddCrypto("eth", "Ethereum", "usd", 10, base); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.DeleteCrypto("eth"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.SaveTrackedCoin(storage.TrackedCoin{ID: "bitcoin", Symbol: "btc", Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopen without Close to replay snapshot plus WAL as after a crash.
+	rs.wal.close()
+	restored, err := NewRamStorage(durableConfig(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Close()
+
+	if _, err := restored.LoginUser("alice", "secret"); err != nil {
+		t.Fatalf("user not restored: %v", err)
+	}
+	page, err := restored.GetCrypto("btc", "usd", storage.HistoryQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Values) != 4 || page.Values[3].Price != 3 {
+		t.Fatalf("history not restored: %+v", page.Values)
+	}
+	if _, err := restored.GetCrypto("eth", "usd", storage.HistoryQuery{}); err == nil {
+		t.Fatal("deleted symbol restored")
+	}
+	coins, _ := restored.GetTrackedCoins()
+	if len(coins) != 1 || coins[0].ID != "bitcoin" {
+		t.Fatalf("tracked coins not restored: %+v", coins)
+	}
+	rules, _ := restored.GetAlertRules("alice")
+	events, _ := restored.GetAlertEvents("r1", 0)
+	if len(rules) != 1 || rules[0] != rule || len(events) != 1 || events[0].Price != 3 {
+		t.Fatalf("alerts not restored: %+v %+v", rules, events)
+	}
+}
+
+func TestDurableRamStorageTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	rs, err := NewRamStorage(durableConfig(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.AddCrypto("btc", "Bitcoin", "usd", 1, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	rs.wal.close()
+
+	f, err := os.OpenFile(filepath.Join(dir, walFile), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"seq":2,"op":"add_cry`)
+	f.Close()
+
+	restored, err := NewRamStorage(durableConfig(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := restored.GetCrypto("btc", "usd", storage.HistoryQuery{})
+	if err != nil || len(page.Values) != 1 {
+		t.Fatalf("got %+v, %v", page.Values, err)
+	}
+	if err := restored.AddCrypto("btc", "Bitcoin", "usd", 2, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	restored.wal.close()
+
+	again, err := NewRamStorage(durableConfig(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer again.Close()
+	page, _ = again.GetCrypto("btc", "usd", storage.HistoryQuery{})
+	if len(page.Values) != 2 {
+		t.Fatalf("expected 2 values after torn record, got %+v", page.Values)
+	}
+}
@@ -0,0 +1,42 @@
+package ramstore
+
+import (
+	"sort"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func (rs *ramStorage) SaveWatchlist(list storage.Watchlist) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	list.Symbols = append([]string(nil), list.Symbols...)
+	return rs.commit(walRecord{Op: opSaveWatchlist, Watchlist: &list})
+}
+
+func (rs *ramStorage) DeleteWatchlist(user, id string) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if list, ok := rs.watchlists[id]; !ok || list.User != user {
+		return storage.ErrWatchlistNotExists
+	}
+	return rs.commit(walRecord{Op: opDeleteWatchlist, Key: id})
+}
+
+func (rs *ramStorage) GetWatchlists(user string) ([]storage.Watchlist, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	res := make([]storage.Watchlist, 0)
+	for _, list := range rs.watchlists {
+		if user == "" || list.User == user {
+			list.Symbols = append([]string{}, list.Symbols...)
+			res = append(res, list)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].CreatedAt.Equal(res[j].CreatedAt) {
+			return res[i].CreatedAt.Before(res[j].CreatedAt)
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res, nil
+}
@@ -0,0 +1,115 @@
+package ramstore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func (rs *ramStorage) SaveWebhook(hook storage.Webhook) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.commit(walRecord{Op: opSaveWebhook, Webhook: &hook})
+}
+
+func (rs *ramStorage) DeleteWebhook(user, id string) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if hook, ok := rs.webhooks[id]; !ok || hook.User != user {
+		return storage.ErrWebhookNotExists
+	}
+	return rs.commit(walRecord{Op: opDeleteWebhook, Key: id})
+}
+
+func (rs *ramStorage) GetWebhooks(user string) ([]storage.Webhook, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	res := make([]storage.Webhook, 0)
+	for _, hook := range rs.webhooks {
+		if user == "" || hook.User == user {
+			res = append(res, hook)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].CreatedAt.Equal(res[j].CreatedAt) {
+			return res[i].CreatedAt.Before(res[j].CreatedAt)
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res, nil
+}
+
+func (rs *ramStorage) SaveDelivery(delivery storage.WebhookDelivery) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if _, ok := rs.webhooks[delivery.WebhookID]; !ok {
+		return storage.ErrWebhookNotExists
+	}
+	return rs.commit(walRecord{Op: opSaveDelivery, Delivery: &delivery})
+}
+
+func (rs *ramStorage) GetDueDeliveries(before time.Time, limit int) ([]storage.WebhookDelivery, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	res := make([]storage.WebhookDelivery, 0)
+	for _, delivery := range rs.deliveries {
+		if delivery.Status == storage.DeliveryPending && !delivery.NextAttempt.After(before) {
+			res = append(res, delivery)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].NextAttempt.Equal(res[j].NextAttempt) {
+			return res[i].NextAttempt.Before(res[j].NextAttempt)
+		}
+		return res[i].ID < res[j].ID
+	})
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+func (rs *ramStorage) GetDeliveries(webhookID, status string, limit int) ([]storage.WebhookDelivery, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	res := make([]storage.WebhookDelivery, 0)
+	for _, delivery := range rs.deliveries {
+		if delivery.WebhookID == webhookID && (status == "" || delivery.Status == status) {
+			res = append(res, delivery)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].CreatedAt.Equal(res[j].CreatedAt) {
+			return res[i].CreatedAt.After(res[j].CreatedAt)
+		}
+		return res[i].ID > res[j].ID
+	})
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+func (rs *ramStorage) PruneDeliveries(before time.Time) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.commit(walRecord{Op: opPruneDeliveries, Before: &before})
+}
+
+func (rs *ramStorage) pruneDeliveries(before time.Time) {
+	for id, delivery := range rs.deliveries {
+		if delivery.Status != storage.DeliveryPending && delivery.UpdatedAt.Before(before) {
+			delete(rs.deliveries, id)
+		}
+	}
+}
+
+func (rs *ramStorage) deleteWebhook(id string) {
+	delete(rs.webhooks, id)
+	for deliveryID, delivery := range rs.deliveries {
+		if delivery.WebhookID == id {
+			delete(rs.deliveries, deliveryID)
+		}
+	}
+}
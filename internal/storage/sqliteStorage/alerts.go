@@ -0,0 +1,110 @@
+package sqliteStorage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func (st *sqliteStorage) SaveAlertRule(rule storage.AlertRule) error {
+	var changedAt sql.NullInt64
+	if !rule.ChangedAt.IsZero() {
+		changedAt = sql.NullInt64{Int64: rule.ChangedAt.UnixNano(), Valid: true}
+	}
+	_, err := st.db.Exec(`INSERT INTO alert_rules
+		(rule_id, user_name, symbol, currency, rule_type, threshold, window_ms, cooldown_ms, triggered, changed_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (rule_id) DO UPDATE
+		SET triggered = EXCLUDED.triggered, changed_at = EXCLUDED.changed_at`,
+		rule.ID, rule.User, rule.Symbol, rule.Currency, rule.Type, rule.Threshold,
+		rule.Window.Milliseconds(), rule.Cooldown.Milliseconds(), rule.Triggered,
+		changedAt, rule.CreatedAt.UnixNano())
+	return err
+}
+
+func (st *sqliteStorage) DeleteAlertRule(user, id string) error {
+	res, err := st.db.Exec(`DELETE FROM alert_rules WHERE rule_id = ? AND user_name = ?`, id, user)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return storage.ErrAlertNotExists
+	}
+	return nil
+}
+
+func (st *sqliteStorage) GetAlertRules(user string) ([]storage.AlertRule, error) {
+	rows, err := st.db.Query(`SELECT rule_id, user_name, symbol, currency, rule_type, threshold,
+		       window_ms, cooldown_ms, triggered, changed_at, created_at
+		FROM alert_rules
+		WHERE ?1 = '' OR user_name = ?1
+		ORDER BY created_at, rule_id`, user)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]storage.AlertRule, 0)
+	for rows.Next() {
+		var rule storage.AlertRule
+		var windowMs, cooldownMs, createdAt int64
+		var changedAt sql.NullInt64
+		if err := rows.Scan(&rule.ID, &rule.User, &rule.Symbol, &rule.Currency, &rule.Type, &rule.Threshold,
+			&windowMs, &cooldownMs, &rule.Triggered, &changedAt, &createdAt); err != nil {
+			return nil, err
+		}
+		rule.Window = time.Duration(windowMs) * time.Millisecond
+		rule.Cooldown = time.Duration(cooldownMs) * time.Millisecond
+		if changedAt.Valid {
+			rule.ChangedAt = time.Unix(0, changedAt.Int64).UTC()
+		}
+		rule.CreatedAt = time.Unix(0, createdAt).UTC()
+		res = append(res, rule)
+	}
+	return res, rows.Err()
+}
+
+func (st *sqliteStorage) AddAlertEvent(event storage.AlertEvent) error {
+	res, err := st.db.Exec(`INSERT INTO alert_events (rule_id, state, price, message, timestamp)
+		SELECT rule_id, ?2, ?3, ?4, ?5 FROM alert_rules WHERE rule_id = ?1`,
+		event.RuleID, event.State, event.Price, event.Message, event.Time.UnixNano())
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return storage.ErrAlertNotExists
+	}
+	return nil
+}
+
+func (st *sqliteStorage) GetAlertEvents(ruleID string, limit int) ([]storage.AlertEvent, error) {
+	query := `SELECT rule_id, state, price, message, timestamp
+		FROM alert_events
+		WHERE rule_id = ?
+		ORDER BY timestamp DESC, event_id DESC`
+	args := []any{ruleID}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := st.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]storage.AlertEvent, 0)
+	for rows.Next() {
+		var event storage.AlertEvent
+		var ts int64
+		if err := rows.Scan(&event.RuleID, &event.State, &event.Price, &event.Message, &ts); err != nil {
+			return nil, err
+		}
+		event.Time = time.Unix(0, ts).UTC()
+		res = append(res, event)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,51 @@
+package sqliteStorage
+
+import (
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func (st *sqliteStorage) GetCandles(symbol, currency string, interval time.Duration, from, to time.Time) ([]storage.Candle, error) {
+	st.mu.RLock()
+	id, ok := st.symbToIDmap[symbol]
+	st.mu.RUnlock()
+	if !ok {
+		return nil, storage.NotTracked(symbol)
+	}
+
+	filter, filterArgs := timeFilter("timestamp", from, to)
+	args := append([]any{int64(interval), id, currency}, filterArgs...)
+
+	rows, err := st.db.Query(`WITH bucketed AS (
+		SELECT timestamp, price, (timestamp / ?1) * ?1 AS bucket
+		FROM crypto_prices
+		WHERE crypto_id = ?2 AND currency = ?3`+filter+`
+	)
+	SELECT DISTINCT bucket,
+	       first_value(price) OVER w,
+	       max(price) OVER w,
+	       min(price) OVER w,
+	       last_value(price) OVER w,
+	       count(*) OVER w
+	FROM bucketed
+	WINDOW w AS (PARTITION BY bucket ORDER BY timestamp
+	             ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING)
+	ORDER BY bucket`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]storage.Candle, 0)
+	for rows.Next() {
+		var c storage.Candle
+		var bucket int64
+		if err := rows.Scan(&bucket, &c.Open, &c.High, &c.Low, &c.Close, &c.SampleCount); err != nil {
+			return nil, err
+		}
+		c.Time = time.Unix(0, bucket).UTC()
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS users (
+    user_name text NOT NULL PRIMARY KEY,
+    password text NOT NULL,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS crypto_info (
+    crypto_id integer PRIMARY KEY AUTOINCREMENT,
+    name text NOT NULL UNIQUE,
+    symbol text NOT NULL
+);
+
+-- timestamp holds unix nanoseconds so that range filters and bucketing are
+-- plain integer arithmetic.
+CREATE TABLE IF NOT EXISTS crypto_prices (
+    crypto_id integer NOT NULL REFERENCES crypto_info(crypto_id),
+    price real NOT NULL,
+    currency text NOT NULL DEFAULT 'usd',
+    timestamp integer NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS crypto_prices_history_idx
+    ON crypto_prices (crypto_id, currency, timestamp);
+
+CREATE TABLE IF NOT EXISTS tracked_coins (
+    coin_id text PRIMARY KEY,
+    symbol text NOT NULL UNIQUE,
+    name text NOT NULL,
+    enabled boolean NOT NULL,
+    interval_ms integer NOT NULL,
+    custom boolean NOT NULL DEFAULT false
+);
+
+CREATE TABLE IF NOT EXISTS updater_settings (
+    id integer PRIMARY KEY CHECK (id = 1),
+    enabled boolean NOT NULL,
+    interval_ms integer NOT NULL
+);
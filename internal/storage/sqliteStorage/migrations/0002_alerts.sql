@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS alert_rules (
+    rule_id text PRIMARY KEY,
+    user_name text NOT NULL,
+    symbol text NOT NULL,
+    currency text NOT NULL,
+    rule_type text NOT NULL,
+    threshold real NOT NULL,
+    window_ms integer NOT NULL DEFAULT 0,
+    cooldown_ms integer NOT NULL DEFAULT 0,
+    triggered boolean NOT NULL DEFAULT false,
+    changed_at integer,
+    created_at integer NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS alert_rules_user_idx ON alert_rules (user_name);
+
+CREATE TABLE IF NOT EXISTS alert_events (
+    event_id integer PRIMARY KEY AUTOINCREMENT,
+    rule_id text NOT NULL REFERENCES alert_rules(rule_id) ON DELETE CASCADE,
+    state text NOT NULL,
+    price real NOT NULL,
+    message text NOT NULL,
+    timestamp integer NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS alert_events_rule_idx ON alert_events (rule_id, timestamp);
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+    webhook_id text PRIMARY KEY,
+    user_name text NOT NULL,
+    url text NOT NULL,
+    secret text NOT NULL,
+    events text NOT NULL,
+    symbols text NOT NULL DEFAULT '',
+    created_at integer NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_user_idx ON webhooks (user_name);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id text PRIMARY KEY,
+    webhook_id text NOT NULL REFERENCES webhooks(webhook_id) ON DELETE CASCADE,
+    event text NOT NULL,
+    payload text NOT NULL,
+    status text NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt integer NOT NULL,
+    last_error text NOT NULL DEFAULT '',
+    response_code integer NOT NULL DEFAULT 0,
+    created_at integer NOT NULL,
+    updated_at integer NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx
+    ON webhook_deliveries (next_attempt) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_log_idx
+    ON webhook_deliveries (webhook_id, created_at);
@@ -0,0 +1,280 @@
+package sqliteStorage
+
+import (
+	"database/sql"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/crypt"
+	"github.com/zenrot/CryptoService/internal/storage"
+	_ "modernc.org/sqlite"
+)
+
+type sqliteStorage struct {
+	mu          sync.RWMutex
+	symbToIDmap map[string]int
+	db          *sql.DB
+}
+
+func NewSQLiteStorage(cfg *config.Config) (*sqliteStorage, error) {
+	db, err := sql.Open("sqlite", dsn(cfg.SQLiteConfig.Path))
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; one connection avoids SQLITE_BUSY and
+	// keeps ":memory:" databases shared.
+	db.SetMaxOpenConns(1)
+	st, err := setup(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return st, nil
+}
+
+func dsn(path string) string {
+	query := url.Values{}
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "busy_timeout(5000)")
+	if path != ":memory:" {
+		query.Add("_pragma", "journal_mode(WAL)")
+	}
+	return "file:" + path + "?" + query.Encode()
+}
+
+func setup(db *sql.DB) (*sqliteStorage, error) {
+	if err := migrate(db); err != nil {
+		return nil, err
+	}
+
+	symbToIDmap := make(map[string]int)
+	rows, err := db.Query(`SELECT crypto_id, symbol FROM crypto_info`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var symbol string
+		if err := rows.Scan(&id, &symbol); err != nil {
+			return nil, err
+		}
+		symbToIDmap[symbol] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &sqliteStorage{
+		symbToIDmap: symbToIDmap,
+		db:          db,
+	}, nil
+}
+
+func (st *sqliteStorage) Close() error {
+	return st.db.Close()
+}
+
+func (st *sqliteStorage) RegisterUser(name, password string) error {
+	hashedPasswd, err := crypt.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	_, err = st.db.Exec(`INSERT INTO users (user_name, password) VALUES (?, ?)`, name, hashedPasswd)
+	if err != nil {
+		return storage.ErrUserExists
+	}
+	return nil
+}
+
+func (st *sqliteStorage) LoginUser(name, password string) (*storage.User, error) {
+	row := st.db.QueryRow(`SELECT user_name, password FROM users WHERE user_name = ?`, name)
+	var user storage.User
+	if err := row.Scan(&user.Name, &user.Password); err != nil {
+		return nil, storage.ErrUserNotExists
+	}
+	if !crypt.CheckPasswordHash(password, user.Password) {
+		return nil, storage.ErrWrongPassword
+	}
+	return &user, nil
+}
+
+func (st *sqliteStorage) AddCrypto(symbol, name, currency string, price float64, t time.Time) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	cryptoID, ok := st.symbToIDmap[symbol]
+	if !ok {
+		err := st.db.QueryRow(
+			`INSERT INTO crypto_info (name, symbol) VALUES (?, ?) RETURNING crypto_id`,
+			name, symbol,
+		).Scan(&cryptoID)
+		if err != nil {
+			return err
+		}
+		st.symbToIDmap[symbol] = cryptoID
+	}
+
+	_, err := st.db.Exec(
+		`INSERT INTO crypto_prices (crypto_id, price, currency, timestamp) VALUES (?, ?, ?, ?)`,
+		cryptoID, price, currency, t.UnixNano(),
+	)
+	return err
+}
+
+func (st *sqliteStorage) GetCrypto(symbol, currency string, query storage.HistoryQuery) (storage.HistoryPage, error) {
+	after, hasCursor, err := query.After()
+	if err != nil {
+		return storage.HistoryPage{}, err
+	}
+	st.mu.RLock()
+	id, ok := st.symbToIDmap[symbol]
+	st.mu.RUnlock()
+	if !ok {
+		return storage.HistoryPage{}, storage.NotTracked(symbol)
+	}
+
+	sqlQuery := `SELECT cp.timestamp, cp.price, ci.name
+		FROM crypto_prices AS cp
+		JOIN crypto_info AS ci USING (crypto_id)
+		WHERE cp.crypto_id = ? AND cp.currency = ?`
+	args := []any{id, currency}
+	filter, filterArgs := timeFilter("cp.timestamp", query.From, query.To)
+	sqlQuery += filter
+	args = append(args, filterArgs...)
+	if hasCursor {
+		sqlQuery += " AND cp.timestamp > ?"
+		args = append(args, after.UnixNano())
+	}
+	sqlQuery += " ORDER BY cp.timestamp"
+	if query.Limit > 0 {
+		sqlQuery += " LIMIT ?"
+		args = append(args, query.Limit+1)
+	}
+
+	rows, err := st.db.Query(sqlQuery, args...)
+	if err != nil {
+		return storage.HistoryPage{}, err
+	}
+	defer rows.Close()
+	res := make([]storage.CryptoVal, 0)
+	for rows.Next() {
+		var value storage.CryptoVal
+		var ts int64
+		if err := rows.Scan(&ts, &value.Price, &value.Name); err != nil {
+			return storage.HistoryPage{}, err
+		}
+		value.Time = time.Unix(0, ts).UTC()
+		value.Symbol = symbol
+		value.Currency = currency
+		res = append(res, value)
+	}
+	if err := rows.Err(); err != nil {
+		return storage.HistoryPage{}, err
+	}
+
+	page := storage.HistoryPage{Values: res}
+	if query.Limit > 0 && len(res) > query.Limit {
+		page.Values = res[:query.Limit]
+		page.NextCursor = storage.EncodeCursor(page.Values[query.Limit-1].Time)
+	}
+	return page, nil
+}
+
+func (st *sqliteStorage) GetLatestCrypto(currency string) (map[string]storage.CryptoVal, error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	res := make(map[string]storage.CryptoVal)
+	for symbol, id := range st.symbToIDmap {
+		value := storage.CryptoVal{Symbol: symbol, Currency: currency}
+		var ts int64
+		err := st.db.QueryRow(`SELECT cp.price, cp.timestamp, ci.name
+			FROM crypto_prices AS cp
+			JOIN crypto_info AS ci USING (crypto_id)
+			WHERE cp.crypto_id = ? AND cp.currency = ?
+			ORDER BY cp.timestamp DESC
+			LIMIT 1`, id, currency).Scan(&value.Price, &ts, &value.Name)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		value.Time = time.Unix(0, ts).UTC()
+		res[symbol] = value
+	}
+	return res, nil
+}
+
+func (st *sqliteStorage) DeleteCrypto(symbol string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	id, ok := st.symbToIDmap[symbol]
+	if !ok {
+		return storage.ErrCryptoNotExists
+	}
+	tx, err := st.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM crypto_prices WHERE crypto_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM crypto_info WHERE crypto_id = ?`, id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	delete(st.symbToIDmap, symbol)
+	return nil
+}
+
+func (st *sqliteStorage) GetCryptoStats(symbol, currency string, from, to time.Time) (storage.CryptoStat, error) {
+	st.mu.RLock()
+	id, ok := st.symbToIDmap[symbol]
+	st.mu.RUnlock()
+	if !ok {
+		return storage.CryptoStat{}, storage.NotTracked(symbol)
+	}
+
+	filter, filterArgs := timeFilter("timestamp", from, to)
+	args := append([]any{id, currency}, filterArgs...)
+
+	var count int
+	var min, max, avg, first, last sql.NullFloat64
+	err := st.db.QueryRow(`SELECT count(*), min(price), max(price), avg(price),
+	       min(first_price), min(last_price)
+	FROM (
+		SELECT price,
+		       first_value(price) OVER w AS first_price,
+		       last_value(price) OVER w AS last_price
+		FROM crypto_prices
+		WHERE crypto_id = ? AND currency = ?`+filter+`
+		WINDOW w AS (ORDER BY timestamp ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING)
+	) AS windowed`, args...).Scan(&count, &min, &max, &avg, &first, &last)
+	if err != nil {
+		return storage.CryptoStat{}, err
+	}
+	if count == 0 {
+		return storage.CryptoStat{}, storage.NoRecords(symbol)
+	}
+	return storage.NewCryptoStat(min.Float64, max.Float64, avg.Float64, first.Float64, last.Float64, count), nil
+}
+
+// timeFilter returns an inclusive range condition on a unix-nanosecond column.
+func timeFilter(column string, from, to time.Time) (string, []any) {
+	filter := ""
+	var args []any
+	if !from.IsZero() {
+		filter += " AND " + column + " >= ?"
+		args = append(args, from.UnixNano())
+	}
+	if !to.IsZero() {
+		filter += " AND " + column + " <= ?"
+		args = append(args, to.UnixNano())
+	}
+	return filter, args
+}
@@ -0,0 +1,153 @@
+package sqliteStorage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/storage"
+	"github.com/zenrot/CryptoService/internal/storage/storagetest"
+)
+
+func newTestStorage(t *testing.T, path string) *sqliteStorage {
+	t.Helper()
+	st, err := NewSQLiteStorage(&config.Config{SQLiteConfig: config.SQLiteConfig{Path: path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() })
+	return st
+}
+
+func TestAuthContract(t *testing.T) {
+	storagetest.TestAuth(t, func(t *testing.T) storage.Auth { return newTestStorage(t, ":memory:") })
+}
+
+func TestCryptoContract(t *testing.T) {
+	storagetest.TestCrypto(t, func(t *testing.T) storage.Crypto { return newTestStorage(t, ":memory:") })
+}
+
+func TestAlertsContract(t *testing.T) {
+	storagetest.TestAlerts(t, func(t *testing.T) storage.Alerts { return newTestStorage(t, ":memory:") })
+}
+
+func TestWebhooksContract(t *testing.T) {
+	storagetest.TestWebhooks(t, func(t *testing.T) storage.Webhooks { return newTestStorage(t, ":memory:") })
+}
+
+func TestWatchlistsContract(t *testing.T) {
+	storagetest.TestWatchlists(t, func(t *testing.T) storage.Watchlists { return newTestStorage(t, ":memory:") })
+}
+
+func seed(t *testing.T, st *sqliteStorage, base time.Time) {
+	t.Helper()
+	for i := 0; i < 10; i++ {
+		if err := st.AddCrypto("btc", "Bitcoin", "usd", float64(i+1), base.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.AddCrypto("btc", "Bitcoin", "eur", 100, base); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHistoryAndLatest(t *testing.T) {
+	st := newTestStorage(t, ":memory:")
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	seed(t, st, base)
+
+	page, err := st.GetCrypto("btc", "usd", storage.HistoryQuery{Limit: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Values) != 4 || page.NextCursor == "" || page.Values[0].Name != "Bitcoin" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+	page, err = st.GetCrypto("btc", "usd", storage.HistoryQuery{Limit: 4, Cursor: page.NextCursor, To: base.Add(6 * time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Values) != 3 || page.NextCursor != "" || !page.Values[0].Time.Equal(base.Add(4*time.Minute)) {
+		t.Fatalf("unexpected second page: %+v", page)
+	}
+
+	latest, err := st.GetLatestCrypto("usd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest["btc"].Price != 10 || !latest["btc"].Time.Equal(base.Add(9*time.Minute)) {
+		t.Fatalf("unexpected latest: %+v", latest)
+	}
+
+	if _, err := st.GetCrypto("eth", "usd", storage.HistoryQuery{}); err == nil {
+		t.Fatal("expected error for untracked symbol")
+	}
+}
+
+func TestStatsAndCandles(t *testing.T) {
+	st := newTestStorage(t, ":memory:")
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	seed(t, st, base)
+
+	stat, err := st.GetCryptoStats("btc", "usd", base.Add(2*time.Minute), base.Add(5*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := storage.NewCryptoStat(3, 6, 4.5, 3, 6, 4)
+	if stat != want {
+		t.Fatalf("got %+v, want %+v", stat, want)
+	}
+
+	candles, err := st.GetCandles("btc", "usd", 5*time.Minute, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 2 {
+		t.Fatalf("expected 2 candles, got %+v", candles)
+	}
+	first := storage.Candle{Time: base, Open: 1, High: 5, Low: 1, Close: 5, SampleCount: 5}
+	if candles[0] != first {
+		t.Fatalf("got %+v, want %+v", candles[0], first)
+	}
+}
+
+func TestDeleteAndReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crypto.db")
+	st := newTestStorage(t, path)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	seed(t, st, base)
+	if err := st.AddCrypto("eth", "Ethereum", "usd", 1, base); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.DeleteCrypto("eth"); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.RegisterUser("alice", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.RegisterUser("alice", "other"); !errors.Is(err, storage.ErrUserExists) {
+		t.Fatalf("expected ErrUserExists, got %v", err)
+	}
+	if err := st.SaveDefaultSchedule(storage.ScheduleSettings{Enabled: true, Interval: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+
+	reopened := newTestStorage(t, path)
+	if _, err := reopened.LoginUser("alice", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	latest, err := reopened.GetLatestCrypto("usd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := latest["eth"]; ok || latest["btc"].Price != 10 {
+		t.Fatalf("unexpected latest after reopen: %+v", latest)
+	}
+	settings, ok, err := reopened.GetDefaultSchedule()
+	if err != nil || !ok || settings.Interval != time.Minute {
+		t.Fatalf("schedule not persisted: %+v %v %v", settings, ok, err)
+	}
+}
@@ -0,0 +1,66 @@
+package sqliteStorage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func (st *sqliteStorage) SaveTrackedCoin(coin storage.TrackedCoin) error {
+	_, err := st.db.Exec(`INSERT INTO tracked_coins (coin_id, symbol, name, enabled, interval_ms, custom)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (coin_id) DO UPDATE
+		SET symbol = EXCLUDED.symbol, name = EXCLUDED.name, enabled = EXCLUDED.enabled,
+		    interval_ms = EXCLUDED.interval_ms, custom = EXCLUDED.custom`,
+		coin.ID, coin.Symbol, coin.Name, coin.Enabled, coin.Interval.Milliseconds(), coin.Custom)
+	return err
+}
+
+func (st *sqliteStorage) DeleteTrackedCoin(id string) error {
+	_, err := st.db.Exec(`DELETE FROM tracked_coins WHERE coin_id = ?`, id)
+	return err
+}
+
+func (st *sqliteStorage) GetTrackedCoins() ([]storage.TrackedCoin, error) {
+	rows, err := st.db.Query(`SELECT coin_id, symbol, name, enabled, interval_ms, custom
+		FROM tracked_coins ORDER BY coin_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]storage.TrackedCoin, 0)
+	for rows.Next() {
+		var coin storage.TrackedCoin
+		var intervalMs int64
+		if err := rows.Scan(&coin.ID, &coin.Symbol, &coin.Name, &coin.Enabled, &intervalMs, &coin.Custom); err != nil {
+			return nil, err
+		}
+		coin.Interval = time.Duration(intervalMs) * time.Millisecond
+		res = append(res, coin)
+	}
+	return res, rows.Err()
+}
+
+func (st *sqliteStorage) SaveDefaultSchedule(settings storage.ScheduleSettings) error {
+	_, err := st.db.Exec(`INSERT INTO updater_settings (id, enabled, interval_ms) VALUES (1, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET enabled = EXCLUDED.enabled, interval_ms = EXCLUDED.interval_ms`,
+		settings.Enabled, settings.Interval.Milliseconds())
+	return err
+}
+
+func (st *sqliteStorage) GetDefaultSchedule() (storage.ScheduleSettings, bool, error) {
+	var settings storage.ScheduleSettings
+	var intervalMs int64
+	err := st.db.QueryRow(`SELECT enabled, interval_ms FROM updater_settings WHERE id = 1`).
+		Scan(&settings.Enabled, &intervalMs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ScheduleSettings{}, false, nil
+	}
+	if err != nil {
+		return storage.ScheduleSettings{}, false, err
+	}
+	settings.Interval = time.Duration(intervalMs) * time.Millisecond
+	return settings, true, nil
+}
@@ -0,0 +1,54 @@
+package sqliteStorage
+
+import (
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func (st *sqliteStorage) SaveWatchlist(list storage.Watchlist) error {
+	_, err := st.db.Exec(`INSERT INTO watchlists (watchlist_id, user_name, name, symbols, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (watchlist_id) DO UPDATE
+		SET name = EXCLUDED.name, symbols = EXCLUDED.symbols, updated_at = EXCLUDED.updated_at`,
+		list.ID, list.User, list.Name, joinList(list.Symbols), list.CreatedAt.UnixNano(), list.UpdatedAt.UnixNano())
+	return err
+}
+
+func (st *sqliteStorage) DeleteWatchlist(user, id string) error {
+	res, err := st.db.Exec(`DELETE FROM watchlists WHERE watchlist_id = ? AND user_name = ?`, id, user)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return storage.ErrWatchlistNotExists
+	}
+	return nil
+}
+
+func (st *sqliteStorage) GetWatchlists(user string) ([]storage.Watchlist, error) {
+	rows, err := st.db.Query(`SELECT watchlist_id, user_name, name, symbols, created_at, updated_at
+		FROM watchlists
+		WHERE ?1 = '' OR user_name = ?1
+		ORDER BY created_at, watchlist_id`, user)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]storage.Watchlist, 0)
+	for rows.Next() {
+		var list storage.Watchlist
+		var symbols string
+		var createdAt, updatedAt int64
+		if err := rows.Scan(&list.ID, &list.User, &list.Name, &symbols, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+		list.Symbols = splitList(symbols)
+		list.CreatedAt = time.Unix(0, createdAt).UTC()
+		list.UpdatedAt = time.Unix(0, updatedAt).UTC()
+		res = append(res, list)
+	}
+	return res, rows.Err()
+}
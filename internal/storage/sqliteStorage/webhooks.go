@@ -0,0 +1,144 @@
+package sqliteStorage
+
+import (
+	"strings"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+func (st *sqliteStorage) SaveWebhook(hook storage.Webhook) error {
+	_, err := st.db.Exec(`INSERT INTO webhooks (webhook_id, user_name, url, secret, events, symbols, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (webhook_id) DO UPDATE
+		SET url = EXCLUDED.url, secret = EXCLUDED.secret, events = EXCLUDED.events, symbols = EXCLUDED.symbols`,
+		hook.ID, hook.User, hook.URL, hook.Secret, joinList(hook.Events), joinList(hook.Symbols), hook.CreatedAt.UnixNano())
+	return err
+}
+
+func (st *sqliteStorage) DeleteWebhook(user, id string) error {
+	res, err := st.db.Exec(`DELETE FROM webhooks WHERE webhook_id = ? AND user_name = ?`, id, user)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return storage.ErrWebhookNotExists
+	}
+	return nil
+}
+
+func (st *sqliteStorage) GetWebhooks(user string) ([]storage.Webhook, error) {
+	rows, err := st.db.Query(`SELECT webhook_id, user_name, url, secret, events, symbols, created_at
+		FROM webhooks
+		WHERE ?1 = '' OR user_name = ?1
+		ORDER BY created_at, webhook_id`, user)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]storage.Webhook, 0)
+	for rows.Next() {
+		var hook storage.Webhook
+		var events, symbols string
+		var createdAt int64
+		if err := rows.Scan(&hook.ID, &hook.User, &hook.URL, &hook.Secret, &events, &symbols, &createdAt); err != nil {
+			return nil, err
+		}
+		hook.Events = splitList(events)
+		hook.Symbols = splitList(symbols)
+		hook.CreatedAt = time.Unix(0, createdAt).UTC()
+		res = append(res, hook)
+	}
+	return res, rows.Err()
+}
+
+func (st *sqliteStorage) SaveDelivery(delivery storage.WebhookDelivery) error {
+	res, err := st.db.Exec(`INSERT INTO webhook_deliveries
+		(delivery_id, webhook_id, event, payload, status, attempts, next_attempt, last_error, response_code, created_at, updated_at)
+		SELECT ?1, webhook_id, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11
+		FROM webhooks WHERE webhook_id = ?2
+		ON CONFLICT (delivery_id) DO UPDATE
+		SET status = EXCLUDED.status, attempts = EXCLUDED.attempts, next_attempt = EXCLUDED.next_attempt,
+		    last_error = EXCLUDED.last_error, response_code = EXCLUDED.response_code, updated_at = EXCLUDED.updated_at`,
+		delivery.ID, delivery.WebhookID, delivery.Event, delivery.Payload, delivery.Status, delivery.Attempts,
+		delivery.NextAttempt.UnixNano(), delivery.LastError, delivery.ResponseCode,
+		delivery.CreatedAt.UnixNano(), delivery.UpdatedAt.UnixNano())
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return storage.ErrWebhookNotExists
+	}
+	return nil
+}
+
+const deliveryColumns = `delivery_id, webhook_id, event, payload, status, attempts, next_attempt,
+		       last_error, response_code, created_at, updated_at`
+
+func (st *sqliteStorage) GetDueDeliveries(before time.Time, limit int) ([]storage.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE status = 'pending' AND next_attempt <= ?
+		ORDER BY next_attempt, delivery_id`
+	args := []any{before.UnixNano()}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	return st.queryDeliveries(query, args...)
+}
+
+func (st *sqliteStorage) GetDeliveries(webhookID, status string, limit int) ([]storage.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE webhook_id = ?1 AND (?2 = '' OR status = ?2)
+		ORDER BY created_at DESC, delivery_id DESC`
+	args := []any{webhookID, status}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	return st.queryDeliveries(query, args...)
+}
+
+func (st *sqliteStorage) queryDeliveries(query string, args ...any) ([]storage.WebhookDelivery, error) {
+	rows, err := st.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]storage.WebhookDelivery, 0)
+	for rows.Next() {
+		var d storage.WebhookDelivery
+		var nextAttempt, createdAt, updatedAt int64
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.Status, &d.Attempts, &nextAttempt,
+			&d.LastError, &d.ResponseCode, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+		d.NextAttempt = time.Unix(0, nextAttempt).UTC()
+		d.CreatedAt = time.Unix(0, createdAt).UTC()
+		d.UpdatedAt = time.Unix(0, updatedAt).UTC()
+		res = append(res, d)
+	}
+	return res, rows.Err()
+}
+
+func (st *sqliteStorage) PruneDeliveries(before time.Time) error {
+	_, err := st.db.Exec(`DELETE FROM webhook_deliveries WHERE status <> 'pending' AND updated_at < ?`, before.UnixNano())
+	return err
+}
+
+func joinList(values []string) string {
+	return strings.Join(values, ",")
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
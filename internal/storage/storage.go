@@ -2,6 +2,7 @@ package storage
 
 import (
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -11,47 +12,271 @@ type User struct {
 }
 
 type CryptoVal struct {
-	Symbol string    `json:"symbol"`
-	Name   string    `json:"name"`
-	Price  float64   `json:"price"`
-	Time   time.Time `json:"time"`
+	Symbol   string    `json:"symbol"`
+	Name     string    `json:"name"`
+	Currency string    `json:"currency"`
+	Price    float64   `json:"price"`
+	Time     time.Time `json:"time"`
 }
 
 type CryptoStat struct {
 	MinPrice           float64 `json:"min_price"`
 	MaxPrice           float64 `json:"max_price"`
 	AvgPrice           float64 `json:"avg_price"`
+	FirstPrice         float64 `json:"first_price"`
+	LastPrice          float64 `json:"last_price"`
 	PriceChange        float64 `json:"price_change"`
 	PriceChangePercent float64 `json:"price_change_percent"`
 	RecordsCount       int     `json:"records_count"`
 }
 
+type Candle struct {
+	Time        time.Time `json:"time"`
+	Open        float64   `json:"open"`
+	High        float64   `json:"high"`
+	Low         float64   `json:"low"`
+	Close       float64   `json:"close"`
+	SampleCount int       `json:"sample_count"`
+}
+
+type TrackedCoin struct {
+	ID       string        `json:"id"`
+	Symbol   string        `json:"symbol"`
+	Name     string        `json:"name"`
+	Enabled  bool          `json:"enabled"`
+	Interval time.Duration `json:"interval"`
+	Custom   bool          `json:"custom"`
+}
+
+type ScheduleSettings struct {
+	Enabled  bool          `json:"enabled"`
+	Interval time.Duration `json:"interval"`
+}
+
+const (
+	AlertAbove       = "above"
+	AlertBelow       = "below"
+	AlertPercentMove = "percent_move"
+	AlertStale       = "stale"
+)
+
+type AlertRule struct {
+	ID        string        `json:"id"`
+	User      string        `json:"user"`
+	Symbol    string        `json:"symbol"`
+	Currency  string        `json:"currency"`
+	Type      string        `json:"type"`
+	Threshold float64       `json:"threshold"`
+	Window    time.Duration `json:"window"`
+	Cooldown  time.Duration `json:"cooldown"`
+	Triggered bool          `json:"triggered"`
+	ChangedAt time.Time     `json:"changed_at"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+const (
+	AlertTriggered = "triggered"
+	AlertCleared   = "cleared"
+)
+
+type AlertEvent struct {
+	RuleID  string    `json:"rule_id"`
+	State   string    `json:"state"`
+	Price   float64   `json:"price"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+type Webhook struct {
+	ID        string    `json:"id"`
+	User      string    `json:"user"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	Events    []string  `json:"events"`
+	Symbols   []string  `json:"symbols"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+type WebhookDelivery struct {
+	ID           string    `json:"id"`
+	WebhookID    string    `json:"webhook_id"`
+	Event        string    `json:"event"`
+	Payload      string    `json:"payload"`
+	Status       string    `json:"status"`
+	Attempts     int       `json:"attempts"`
+	NextAttempt  time.Time `json:"next_attempt"`
+	LastError    string    `json:"last_error"`
+	ResponseCode int       `json:"response_code"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type Watchlist struct {
+	ID        string    `json:"id"`
+	User      string    `json:"user"`
+	Name      string    `json:"name"`
+	Symbols   []string  `json:"symbols"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SeriesUsage struct {
+	Symbol   string    `json:"symbol"`
+	Currency string    `json:"currency"`
+	Samples  int       `json:"samples"`
+	Capacity int       `json:"capacity"`
+	Bytes    int64     `json:"bytes"`
+	Oldest   time.Time `json:"oldest"`
+}
+
+type MemoryUsage struct {
+	Bytes    int64         `json:"bytes"`
+	Samples  int           `json:"samples"`
+	Capacity int           `json:"capacity"`
+	MaxAge   string        `json:"max_age"`
+	Series   []SeriesUsage `json:"series"`
+}
+
 type Auth interface {
 	RegisterUser(name, password string) error
 	LoginUser(name, password string) (*User, error)
+	Close() error
 }
 
 type Crypto interface {
-	AddCrypto(symbol, name string, price float64, time time.Time) error
-	GetCrypto(symbol string) ([]CryptoVal, error)
+	AddCrypto(symbol, name, currency string, price float64, time time.Time) error
+	GetCrypto(symbol, currency string, query HistoryQuery) (HistoryPage, error)
 	DeleteCrypto(symbol string) error
-	GetLatestCrypto() (map[string]CryptoVal, error)
-	GetCryptoStats(symbol string) (CryptoStat, error)
+	GetLatestCrypto(currency string) (map[string]CryptoVal, error)
+	GetCryptoStats(symbol, currency string, from, to time.Time) (CryptoStat, error)
+	GetCandles(symbol, currency string, interval time.Duration, from, to time.Time) ([]Candle, error)
+	Close() error
+}
+
+type Tracking interface {
+	SaveTrackedCoin(coin TrackedCoin) error
+	DeleteTrackedCoin(id string) error
+	GetTrackedCoins() ([]TrackedCoin, error)
+	SaveDefaultSchedule(settings ScheduleSettings) error
+	GetDefaultSchedule() (ScheduleSettings, bool, error)
+}
+
+// Alerts stores alert rules and their state changes. GetAlertRules returns
+// the rules of every user for an empty user; GetAlertEvents returns the
+// newest events first.
+type Alerts interface {
+	SaveAlertRule(rule AlertRule) error
+	DeleteAlertRule(user, id string) error
+	GetAlertRules(user string) ([]AlertRule, error)
+	AddAlertEvent(event AlertEvent) error
+	GetAlertEvents(ruleID string, limit int) ([]AlertEvent, error)
+}
+
+// Webhooks stores webhook subscriptions and their delivery queue.
+// GetDueDeliveries returns pending deliveries with NextAttempt not after
+// before, oldest first; GetDeliveries returns the newest first, all statuses
+// for an empty status. PruneDeliveries removes finished deliveries last
+// updated before the given time.
+type Webhooks interface {
+	SaveWebhook(hook Webhook) error
+	DeleteWebhook(user, id string) error
+	GetWebhooks(user string) ([]Webhook, error)
+	SaveDelivery(delivery WebhookDelivery) error
+	GetDueDeliveries(before time.Time, limit int) ([]WebhookDelivery, error)
+	GetDeliveries(webhookID, status string, limit int) ([]WebhookDelivery, error)
+	PruneDeliveries(before time.Time) error
+}
+
+// Watchlists stores named symbol lists. GetWatchlists returns the lists of
+// every user for an empty user.
+type Watchlists interface {
+	SaveWatchlist(list Watchlist) error
+	DeleteWatchlist(user, id string) error
+	GetWatchlists(user string) ([]Watchlist, error)
+}
+
+// MemoryReporter is implemented by storages that keep price history in memory.
+type MemoryReporter interface {
+	MemoryUsage() MemoryUsage
+}
+
+type CryptoTracking interface {
+	Crypto
+	Tracking
 }
 
 type AuthCrypto interface {
 	Auth
 	Crypto
+	Tracking
+	Alerts
+	Webhooks
+	Watchlists
 }
 
+const DefaultCurrency = "usd"
+
 var (
-	ErrUserExists      = errors.New("user already exists")
-	ErrUserNotExists   = errors.New("user does not exists")
-	ErrWrongPassword   = errors.New("wrong password")
-	ErrCryptoExists    = errors.New("crypto already exists")
-	ErrCryptoNotExists = errors.New("crypto does not exists")
+	ErrUserExists         = errors.New("user already exists")
+	ErrUserNotExists      = errors.New("user does not exists")
+	ErrWrongPassword      = errors.New("wrong password")
+	ErrCryptoExists       = errors.New("crypto already exists")
+	ErrCryptoNotExists    = errors.New("crypto does not exists")
+	ErrNoRecords          = errors.New("no records")
+	ErrAlertNotExists     = errors.New("alert does not exists")
+	ErrWebhookNotExists   = errors.New("webhook does not exists")
+	ErrWatchlistNotExists = errors.New("watchlist does not exists")
 )
 
+type symbolError struct {
+	msg  string
+	kind error
+}
+
+func (e *symbolError) Error() string {
+	return e.msg
+}
+
+func (e *symbolError) Unwrap() error {
+	return e.kind
+}
+
+// NotTracked is returned for symbols the storage has no data for; it matches
+// ErrCryptoNotExists.
+func NotTracked(symbol string) error {
+	return &symbolError{msg: fmt.Sprintf("symbol %s is not being tracked", symbol), kind: ErrCryptoNotExists}
+}
+
+// NoRecords is returned when a tracked symbol has no samples in the requested
+// range; it matches ErrNoRecords.
+func NoRecords(symbol string) error {
+	return &symbolError{msg: fmt.Sprintf("no records for %s", symbol), kind: ErrNoRecords}
+}
+
+func NewCryptoStat(min, max, avg, first, last float64, count int) CryptoStat {
+	priceChange := last - min
+	priceChangePercent := 0.0
+	if min != 0 {
+		priceChangePercent = (priceChange / min) * 100
+	}
+	return CryptoStat{
+		MinPrice:           min,
+		MaxPrice:           max,
+		AvgPrice:           avg,
+		FirstPrice:         first,
+		LastPrice:          last,
+		PriceChange:        priceChange,
+		PriceChangePercent: priceChangePercent,
+		RecordsCount:       count,
+	}
+}
+
 func NewUser(name, password string) User {
 	return User{
 		Name:     name,
@@ -59,11 +284,12 @@ func NewUser(name, password string) User {
 	}
 }
 
-func NewCryptoVal(symbol, name string, price float64, time time.Time) CryptoVal {
+func NewCryptoVal(symbol, name, currency string, price float64, time time.Time) CryptoVal {
 	return CryptoVal{
-		Symbol: symbol,
-		Name:   name,
-		Price:  price,
-		Time:   time,
+		Symbol:   symbol,
+		Name:     name,
+		Currency: currency,
+		Price:    price,
+		Time:     time,
 	}
 }
@@ -0,0 +1,645 @@
+// Package storagetest is the behavioural contract every storage backend runs
+// from its own tests.
+package storagetest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+// base is whole seconds in UTC so that every backend round-trips it exactly.
+var base = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func at(minute int) time.Time {
+	return base.Add(time.Duration(minute) * time.Minute)
+}
+
+// TestAuth runs the storage.Auth contract. newStore must return an empty
+// store; the suite closes it.
+func TestAuth(t *testing.T, newStore func(t *testing.T) storage.Auth) {
+	open := func(t *testing.T) storage.Auth {
+		st := newStore(t)
+		t.Cleanup(func() { st.Close() })
+		return st
+	}
+
+	t.Run("RegisterAndLogin", func(t *testing.T) {
+		st := open(t)
+		if err := st.RegisterUser("alice", "secret"); err != nil {
+			t.Fatal(err)
+		}
+		user, err := st.LoginUser("alice", "secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Name != "alice" || user.Password == "secret" {
+			t.Fatalf("unexpected user %+v", user)
+		}
+		if err := st.RegisterUser("alice", "other"); !errors.Is(err, storage.ErrUserExists) {
+			t.Fatalf("duplicate register: got %v, want ErrUserExists", err)
+		}
+		if _, err := st.LoginUser("alice", "wrong"); !errors.Is(err, storage.ErrWrongPassword) {
+			t.Fatalf("wrong password: got %v, want ErrWrongPassword", err)
+		}
+		if _, err := st.LoginUser("bob", "secret"); !errors.Is(err, storage.ErrUserNotExists) {
+			t.Fatalf("unknown user: got %v, want ErrUserNotExists", err)
+		}
+	})
+
+	t.Run("ConcurrentRegister", func(t *testing.T) {
+		st := open(t)
+		const workers = 3
+		errs := make(chan error, workers)
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- st.RegisterUser("carol", "secret")
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		succeeded := 0
+		for err := range errs {
+			switch {
+			case err == nil:
+				succeeded++
+			case !errors.Is(err, storage.ErrUserExists):
+				t.Fatalf("unexpected error %v", err)
+			}
+		}
+		if succeeded != 1 {
+			t.Fatalf("%d registrations succeeded, want 1", succeeded)
+		}
+	})
+}
+
+// TestCrypto runs the storage.Crypto contract. newStore must return an empty
+// store keeping at least 100 samples per symbol and currency; the suite
+// closes it.
+func TestCrypto(t *testing.T, newStore func(t *testing.T) storage.Crypto) {
+	open := func(t *testing.T) storage.Crypto {
+		st := newStore(t)
+		t.Cleanup(func() { st.Close() })
+		return st
+	}
+	add := func(t *testing.T, st storage.Crypto, symbol, currency string, price float64, ts time.Time) {
+		t.Helper()
+		if err := st.AddCrypto(symbol, symbol+" coin", currency, price, ts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("History", func(t *testing.T) {
+		st := open(t)
+		for _, m := range []int{0, 1, 3, 2, 4} {
+			add(t, st, "btc", "usd", float64(m), at(m))
+		}
+		add(t, st, "btc", "eur", 100, at(0))
+
+		page, err := st.GetCrypto("btc", "usd", storage.HistoryQuery{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Values) != 5 || page.NextCursor != "" {
+			t.Fatalf("got %d values, cursor %q", len(page.Values), page.NextCursor)
+		}
+		for i, v := range page.Values {
+			if !v.Time.Equal(at(i)) || v.Price != float64(i) {
+				t.Fatalf("value %d: got %+v, want time order", i, v)
+			}
+			if v.Symbol != "btc" || v.Name != "btc coin" || v.Currency != "usd" {
+				t.Fatalf("value %d: unexpected fields %+v", i, v)
+			}
+		}
+
+		page, err = st.GetCrypto("btc", "usd", storage.HistoryQuery{From: at(1), To: at(3)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Values) != 3 || !page.Values[0].Time.Equal(at(1)) || !page.Values[2].Time.Equal(at(3)) {
+			t.Fatalf("from/to must be inclusive: %+v", page.Values)
+		}
+
+		page, err = st.GetCrypto("btc", "jpy", storage.HistoryQuery{})
+		if err != nil || len(page.Values) != 0 {
+			t.Fatalf("currency without data: got %+v, %v", page.Values, err)
+		}
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		st := open(t)
+		for m := 0; m < 7; m++ {
+			add(t, st, "btc", "usd", float64(m), at(m))
+		}
+		var got []float64
+		query := storage.HistoryQuery{Limit: 3}
+		for pages := 0; ; pages++ {
+			if pages > 3 {
+				t.Fatal("pagination does not terminate")
+			}
+			page, err := st.GetCrypto("btc", "usd", query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, v := range page.Values {
+				got = append(got, v.Price)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+		if fmt.Sprint(got) != "[0 1 2 3 4 5 6]" {
+			t.Fatalf("pages returned %v", got)
+		}
+
+		_, err := st.GetCrypto("btc", "usd", storage.HistoryQuery{Cursor: "not a cursor"})
+		if !errors.Is(err, storage.ErrInvalidCursor) {
+			t.Fatalf("got %v, want ErrInvalidCursor", err)
+		}
+	})
+
+	t.Run("Untracked", func(t *testing.T) {
+		st := open(t)
+		if _, err := st.GetCrypto("nope", "usd", storage.HistoryQuery{}); !errors.Is(err, storage.ErrCryptoNotExists) {
+			t.Fatalf("GetCrypto: got %v, want ErrCryptoNotExists", err)
+		}
+		if _, err := st.GetCryptoStats("nope", "usd", time.Time{}, time.Time{}); !errors.Is(err, storage.ErrCryptoNotExists) {
+			t.Fatalf("GetCryptoStats: got %v, want ErrCryptoNotExists", err)
+		}
+		if _, err := st.GetCandles("nope", "usd", time.Hour, time.Time{}, time.Time{}); !errors.Is(err, storage.ErrCryptoNotExists) {
+			t.Fatalf("GetCandles: got %v, want ErrCryptoNotExists", err)
+		}
+		if err := st.DeleteCrypto("nope"); !errors.Is(err, storage.ErrCryptoNotExists) {
+			t.Fatalf("DeleteCrypto: got %v, want ErrCryptoNotExists", err)
+		}
+	})
+
+	t.Run("Latest", func(t *testing.T) {
+		st := open(t)
+		add(t, st, "btc", "usd", 2, at(2))
+		add(t, st, "btc", "usd", 1, at(1))
+		add(t, st, "eth", "usd", 10, at(0))
+		add(t, st, "sol", "eur", 5, at(0))
+
+		latest, err := st.GetLatestCrypto("usd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(latest) != 2 {
+			t.Fatalf("got %d symbols, want btc and eth: %+v", len(latest), latest)
+		}
+		btc := latest["btc"]
+		if btc.Price != 2 || !btc.Time.Equal(at(2)) || btc.Symbol != "btc" || btc.Name != "btc coin" || btc.Currency != "usd" {
+			t.Fatalf("latest must be the newest sample: %+v", btc)
+		}
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		st := open(t)
+		for m, price := range []float64{4, 2, 8, 6} {
+			add(t, st, "btc", "usd", price, at(m))
+		}
+		stat, err := st.GetCryptoStats("btc", "usd", time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := storage.NewCryptoStat(2, 8, 5, 4, 6, 4); stat != want {
+			t.Fatalf("got %+v, want %+v", stat, want)
+		}
+		stat, err = st.GetCryptoStats("btc", "usd", at(1), at(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := storage.NewCryptoStat(2, 8, 5, 2, 8, 2); stat != want {
+			t.Fatalf("window: got %+v, want %+v", stat, want)
+		}
+		if _, err := st.GetCryptoStats("btc", "usd", at(10), at(20)); !errors.Is(err, storage.ErrNoRecords) {
+			t.Fatalf("empty window: got %v, want ErrNoRecords", err)
+		}
+	})
+
+	t.Run("Candles", func(t *testing.T) {
+		st := open(t)
+		for m, price := range []float64{3, 5, 1, 4, 9} {
+			add(t, st, "btc", "usd", price, at(m*2))
+		}
+		candles, err := st.GetCandles("btc", "usd", 5*time.Minute, time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []storage.Candle{
+			{Time: at(0), Open: 3, High: 5, Low: 1, Close: 1, SampleCount: 3},
+			{Time: at(5), Open: 4, High: 9, Low: 4, Close: 9, SampleCount: 2},
+		}
+		if len(candles) != len(want) {
+			t.Fatalf("got %+v, want %+v", candles, want)
+		}
+		for i := range want {
+			if !candles[i].Time.Equal(want[i].Time) || candles[i].Open != want[i].Open || candles[i].High != want[i].High ||
+				candles[i].Low != want[i].Low || candles[i].Close != want[i].Close || candles[i].SampleCount != want[i].SampleCount {
+				t.Fatalf("candle %d: got %+v, want %+v", i, candles[i], want[i])
+			}
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		st := open(t)
+		add(t, st, "btc", "usd", 1, at(0))
+		add(t, st, "eth", "usd", 2, at(0))
+		if err := st.DeleteCrypto("btc"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.GetCrypto("btc", "usd", storage.HistoryQuery{}); !errors.Is(err, storage.ErrCryptoNotExists) {
+			t.Fatalf("deleted symbol: got %v, want ErrCryptoNotExists", err)
+		}
+		if err := st.DeleteCrypto("btc"); !errors.Is(err, storage.ErrCryptoNotExists) {
+			t.Fatalf("second delete: got %v, want ErrCryptoNotExists", err)
+		}
+		latest, err := st.GetLatestCrypto("usd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := latest["btc"]; ok || len(latest) != 1 {
+			t.Fatalf("latest after delete: %+v", latest)
+		}
+
+		add(t, st, "btc", "usd", 3, at(1))
+		page, err := st.GetCrypto("btc", "usd", storage.HistoryQuery{})
+		if err != nil || len(page.Values) != 1 || page.Values[0].Price != 3 {
+			t.Fatalf("re-added symbol: got %+v, %v", page.Values, err)
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		st := open(t)
+		const workers, samples = 4, 25
+		var wg sync.WaitGroup
+		errs := make(chan error, workers*2)
+		for w := 0; w < workers; w++ {
+			symbol := fmt.Sprintf("coin%d", w)
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < samples; i++ {
+					if err := st.AddCrypto(symbol, symbol, "usd", float64(i), at(i)); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for i := 0; i < samples; i++ {
+					if _, err := st.GetLatestCrypto("usd"); err != nil {
+						errs <- err
+						return
+					}
+					_, err := st.GetCrypto(symbol, "usd", storage.HistoryQuery{Limit: 10})
+					if err != nil && !errors.Is(err, storage.ErrCryptoNotExists) {
+						errs <- err
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Fatal(err)
+		}
+
+		latest, err := st.GetLatestCrypto("usd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(latest) != workers {
+			t.Fatalf("got %d symbols, want %d", len(latest), workers)
+		}
+		for w := 0; w < workers; w++ {
+			symbol := fmt.Sprintf("coin%d", w)
+			page, err := st.GetCrypto(symbol, "usd", storage.HistoryQuery{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(page.Values) != samples || latest[symbol].Price != samples-1 {
+				t.Fatalf("%s: got %d samples, latest %+v", symbol, len(page.Values), latest[symbol])
+			}
+		}
+	})
+}
+
+// TestAlerts runs the storage.Alerts contract. newStore must return an empty
+// store; the suite closes it when it has a Close method.
+func TestAlerts(t *testing.T, newStore func(t *testing.T) storage.Alerts) {
+	open := func(t *testing.T) storage.Alerts {
+		st := newStore(t)
+		if closer, ok := st.(interface{ Close() error }); ok {
+			t.Cleanup(func() { closer.Close() })
+		}
+		return st
+	}
+	rule := func(id, user string, minute int) storage.AlertRule {
+		return storage.AlertRule{
+			ID:        id,
+			User:      user,
+			Symbol:    "btc",
+			Currency:  "usd",
+			Type:      storage.AlertPercentMove,
+			Threshold: 5,
+			Window:    10 * time.Minute,
+			Cooldown:  time.Minute,
+			CreatedAt: at(minute),
+		}
+	}
+
+	t.Run("Rules", func(t *testing.T) {
+		st := open(t)
+		for _, r := range []storage.AlertRule{rule("b", "alice", 1), rule("a", "alice", 2), rule("c", "bob", 0)} {
+			if err := st.SaveAlertRule(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		rules, err := st.GetAlertRules("alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rules) != 2 || rules[0] != rule("b", "alice", 1) || rules[1].ID != "a" {
+			t.Fatalf("unexpected alice rules %+v", rules)
+		}
+		all, err := st.GetAlertRules("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != 3 || all[0].ID != "c" {
+			t.Fatalf("unexpected rules %+v", all)
+		}
+
+		updated := rule("b", "alice", 1)
+		updated.Triggered = true
+		updated.ChangedAt = at(5)
+		if err := st.SaveAlertRule(updated); err != nil {
+			t.Fatal(err)
+		}
+		rules, err = st.GetAlertRules("alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rules[0] != updated {
+			t.Fatalf("got %+v, want %+v", rules[0], updated)
+		}
+
+		if err := st.DeleteAlertRule("bob", "a"); !errors.Is(err, storage.ErrAlertNotExists) {
+			t.Fatalf("foreign delete: got %v, want ErrAlertNotExists", err)
+		}
+		if err := st.DeleteAlertRule("alice", "a"); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.DeleteAlertRule("alice", "a"); !errors.Is(err, storage.ErrAlertNotExists) {
+			t.Fatalf("second delete: got %v, want ErrAlertNotExists", err)
+		}
+	})
+
+	t.Run("Events", func(t *testing.T) {
+		st := open(t)
+		if err := st.SaveAlertRule(rule("r", "alice", 0)); err != nil {
+			t.Fatal(err)
+		}
+		states := []string{storage.AlertTriggered, storage.AlertCleared, storage.AlertTriggered}
+		for i, state := range states {
+			event := storage.AlertEvent{RuleID: "r", State: state, Price: float64(i), Message: fmt.Sprint(i), Time: at(i)}
+			if err := st.AddAlertEvent(event); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := st.AddAlertEvent(storage.AlertEvent{RuleID: "missing", State: storage.AlertTriggered, Time: at(0)}); !errors.Is(err, storage.ErrAlertNotExists) {
+			t.Fatalf("event for unknown rule: got %v, want ErrAlertNotExists", err)
+		}
+
+		events, err := st.GetAlertEvents("r", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := storage.AlertEvent{RuleID: "r", State: storage.AlertTriggered, Price: 2, Message: "2", Time: at(2)}
+		if len(events) != 2 || events[0] != want || events[1].State != storage.AlertCleared {
+			t.Fatalf("unexpected events %+v", events)
+		}
+		if events, err = st.GetAlertEvents("r", 0); err != nil || len(events) != 3 {
+			t.Fatalf("expected all 3 events, got %+v, %v", events, err)
+		}
+
+		if err := st.DeleteAlertRule("alice", "r"); err != nil {
+			t.Fatal(err)
+		}
+		if events, err = st.GetAlertEvents("r", 0); err != nil || len(events) != 0 {
+			t.Fatalf("expected events to be deleted with the rule, got %+v, %v", events, err)
+		}
+	})
+}
+
+// TestWebhooks runs the storage.Webhooks contract. newStore must return an
+// empty store; the suite closes it when it has a Close method.
+func TestWebhooks(t *testing.T, newStore func(t *testing.T) storage.Webhooks) {
+	open := func(t *testing.T) storage.Webhooks {
+		st := newStore(t)
+		if closer, ok := st.(interface{ Close() error }); ok {
+			t.Cleanup(func() { closer.Close() })
+		}
+		return st
+	}
+	hook := func(id, user string, minute int) storage.Webhook {
+		return storage.Webhook{
+			ID:        id,
+			User:      user,
+			URL:       "http://example.com/" + id,
+			Secret:    "secret",
+			Events:    []string{"price.updated", "coin.added"},
+			Symbols:   []string{},
+			CreatedAt: at(minute),
+		}
+	}
+	delivery := func(id, hookID string, minute int) storage.WebhookDelivery {
+		return storage.WebhookDelivery{
+			ID:          id,
+			WebhookID:   hookID,
+			Event:       "price.updated",
+			Payload:     `{"event":"price.updated"}`,
+			Status:      storage.DeliveryPending,
+			NextAttempt: at(minute),
+			CreatedAt:   at(minute),
+			UpdatedAt:   at(minute),
+		}
+	}
+
+	t.Run("Webhooks", func(t *testing.T) {
+		st := open(t)
+		filtered := hook("b", "alice", 0)
+		filtered.Symbols = []string{"btc", "eth"}
+		for _, h := range []storage.Webhook{hook("a", "alice", 1), filtered, hook("c", "bob", 2)} {
+			if err := st.SaveWebhook(h); err != nil {
+				t.Fatal(err)
+			}
+		}
+		hooks, err := st.GetWebhooks("alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hooks) != 2 || hooks[0].ID != "b" || hooks[1].ID != "a" {
+			t.Fatalf("unexpected alice webhooks %+v", hooks)
+		}
+		if got := hooks[0]; got.URL != filtered.URL || got.Secret != "secret" || !got.CreatedAt.Equal(filtered.CreatedAt) ||
+			fmt.Sprint(got.Events, got.Symbols) != fmt.Sprint(filtered.Events, filtered.Symbols) {
+			t.Fatalf("got %+v, want %+v", got, filtered)
+		}
+		if all, err := st.GetWebhooks(""); err != nil || len(all) != 3 {
+			t.Fatalf("expected 3 webhooks, got %+v, %v", all, err)
+		}
+		if err := st.DeleteWebhook("bob", "a"); !errors.Is(err, storage.ErrWebhookNotExists) {
+			t.Fatalf("foreign delete: got %v, want ErrWebhookNotExists", err)
+		}
+		if err := st.DeleteWebhook("alice", "a"); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.DeleteWebhook("alice", "a"); !errors.Is(err, storage.ErrWebhookNotExists) {
+			t.Fatalf("second delete: got %v, want ErrWebhookNotExists", err)
+		}
+	})
+
+	t.Run("Deliveries", func(t *testing.T) {
+		st := open(t)
+		if err := st.SaveWebhook(hook("h", "alice", 0)); err != nil {
+			t.Fatal(err)
+		}
+		for i, id := range []string{"d1", "d2", "d3"} {
+			if err := st.SaveDelivery(delivery(id, "h", i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := st.SaveDelivery(delivery("x", "missing", 0)); !errors.Is(err, storage.ErrWebhookNotExists) {
+			t.Fatalf("delivery for unknown webhook: got %v, want ErrWebhookNotExists", err)
+		}
+
+		due, err := st.GetDueDeliveries(at(1), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(due) != 2 || due[0] != delivery("d1", "h", 0) || due[1].ID != "d2" {
+			t.Fatalf("unexpected due deliveries %+v", due)
+		}
+
+		done := delivery("d1", "h", 0)
+		done.Status = storage.DeliveryDelivered
+		done.Attempts = 1
+		done.ResponseCode = 200
+		done.UpdatedAt = at(1)
+		if err := st.SaveDelivery(done); err != nil {
+			t.Fatal(err)
+		}
+		retry := delivery("d2", "h", 1)
+		retry.Attempts = 1
+		retry.LastError = "503 Service Unavailable"
+		retry.NextAttempt = at(10)
+		if err := st.SaveDelivery(retry); err != nil {
+			t.Fatal(err)
+		}
+		if due, err = st.GetDueDeliveries(at(5), 1); err != nil || len(due) != 1 || due[0].ID != "d3" {
+			t.Fatalf("expected only d3 due, got %+v, %v", due, err)
+		}
+
+		log, err := st.GetDeliveries("h", "", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(log) != 3 || log[0].ID != "d3" || log[1] != retry || log[2] != done {
+			t.Fatalf("unexpected delivery log %+v", log)
+		}
+		if log, err = st.GetDeliveries("h", storage.DeliveryDelivered, 5); err != nil || len(log) != 1 || log[0].ID != "d1" {
+			t.Fatalf("expected only d1 delivered, got %+v, %v", log, err)
+		}
+
+		if err := st.PruneDeliveries(at(30)); err != nil {
+			t.Fatal(err)
+		}
+		if log, err = st.GetDeliveries("h", "", 0); err != nil || len(log) != 2 {
+			t.Fatalf("expected pending deliveries to survive pruning, got %+v, %v", log, err)
+		}
+		if err := st.DeleteWebhook("alice", "h"); err != nil {
+			t.Fatal(err)
+		}
+		if due, err = st.GetDueDeliveries(at(60), 0); err != nil || len(due) != 0 {
+			t.Fatalf("expected deliveries to be deleted with the webhook, got %+v, %v", due, err)
+		}
+	})
+}
+
+// TestWatchlists runs the storage.Watchlists contract. newStore must return
+// an empty store; the suite closes it when it has a Close method.
+func TestWatchlists(t *testing.T, newStore func(t *testing.T) storage.Watchlists) {
+	st := newStore(t)
+	if closer, ok := st.(interface{ Close() error }); ok {
+		t.Cleanup(func() { closer.Close() })
+	}
+	list := func(id, user string, minute int, symbols ...string) storage.Watchlist {
+		return storage.Watchlist{
+			ID:        id,
+			User:      user,
+			Name:      "list " + id,
+			Symbols:   symbols,
+			CreatedAt: at(minute),
+			UpdatedAt: at(minute),
+		}
+	}
+
+	empty := list("b", "alice", 0)
+	empty.Symbols = []string{}
+	for _, l := range []storage.Watchlist{list("a", "alice", 1, "btc", "eth"), empty, list("c", "bob", 2, "btc")} {
+		if err := st.SaveWatchlist(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lists, err := st.GetWatchlists("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lists) != 2 || lists[0].ID != "b" || lists[1].ID != "a" || len(lists[0].Symbols) != 0 {
+		t.Fatalf("unexpected alice watchlists %+v", lists)
+	}
+	if all, err := st.GetWatchlists(""); err != nil || len(all) != 3 {
+		t.Fatalf("expected 3 watchlists, got %+v, %v", all, err)
+	}
+
+	updated := list("a", "alice", 1, "eth", "sol", "doge")
+	updated.Name = "renamed"
+	updated.UpdatedAt = at(5)
+	if err := st.SaveWatchlist(updated); err != nil {
+		t.Fatal(err)
+	}
+	lists, err = st.GetWatchlists("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := lists[1]; got.Name != "renamed" || fmt.Sprint(got.Symbols) != "[eth sol doge]" ||
+		!got.CreatedAt.Equal(at(1)) || !got.UpdatedAt.Equal(at(5)) {
+		t.Fatalf("got %+v, want %+v", got, updated)
+	}
+
+	if err := st.DeleteWatchlist("bob", "a"); !errors.Is(err, storage.ErrWatchlistNotExists) {
+		t.Fatalf("foreign delete: got %v, want ErrWatchlistNotExists", err)
+	}
+	if err := st.DeleteWatchlist("alice", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.DeleteWatchlist("alice", "a"); !errors.Is(err, storage.ErrWatchlistNotExists) {
+		t.Fatalf("second delete: got %v, want ErrWatchlistNotExists", err)
+	}
+	if lists, err = st.GetWatchlists("bob"); err != nil || len(lists) != 1 || fmt.Sprint(lists[0].Symbols) != "[btc]" {
+		t.Fatalf("unexpected bob watchlists %+v, %v", lists, err)
+	}
+}
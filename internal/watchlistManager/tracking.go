@@ -0,0 +1,141 @@
+package watchlistManager
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zenrot/CryptoService/internal/priceSource"
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
+)
+
+type trackingUpdater struct {
+	priceUpdater.PriceUpdater
+	m *Manager
+}
+
+// Tracking returns the updater with coins added and deleted through it kept
+// on the shared list, so that deleting a coin only untracks it when no
+// watchlist uses it. Deleting a coin that is still watched fails with
+// ErrWatched.
+func (m *Manager) Tracking() priceUpdater.PriceUpdater {
+	return &trackingUpdater{PriceUpdater: m.updater, m: m}
+}
+
+func (tu *trackingUpdater) AddCryptoTracking(Symbol string) (priceSource.CoinInfo, error) {
+	return tu.pin(Symbol, func(c priceSource.CoinInfo) bool { return c.Symbol == Symbol }, func() (priceSource.CoinInfo, error) {
+		return tu.PriceUpdater.AddCryptoTracking(Symbol)
+	})
+}
+
+func (tu *trackingUpdater) AddCryptoTrackingByID(ID string) (priceSource.CoinInfo, error) {
+	return tu.pin(ID, func(c priceSource.CoinInfo) bool { return c.ID == ID }, func() (priceSource.CoinInfo, error) {
+		return tu.PriceUpdater.AddCryptoTrackingByID(ID)
+	})
+}
+
+// pin adds the coin matching match to the shared list, calling track first
+// when it is not tracked yet. track queries the price provider, so it runs
+// with m.mu released and the coin is looked up again afterwards.
+func (tu *trackingUpdater) pin(key string, match func(priceSource.CoinInfo) bool, track func() (priceSource.CoinInfo, error)) (priceSource.CoinInfo, error) {
+	m := tu.m
+	var tracked []string
+	var conflict error
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer func() { m.drop(tracked) }()
+	for {
+		coin, ok, err := m.trackedCoin(match)
+		if err != nil {
+			return priceSource.CoinInfo{}, err
+		}
+		if ok {
+			pinned := m.lists[pinnedID]
+			if contains(pinned.Symbols, coin.Symbol) {
+				return priceSource.CoinInfo{}, fmt.Errorf("%w: %s", priceUpdater.ErrAlreadyTracked, key)
+			}
+			return coin, m.replace(pinned, pinned.Name, append(append([]string{}, pinned.Symbols...), coin.Symbol))
+		}
+		if conflict != nil {
+			return priceSource.CoinInfo{}, conflict
+		}
+
+		m.mu.Unlock()
+		coin, err = track()
+		m.mu.Lock()
+		switch {
+		case err == nil:
+			tracked = append(tracked, coin.Symbol)
+		case errors.Is(err, priceUpdater.ErrAlreadyTracked):
+			conflict = err
+		default:
+			return priceSource.CoinInfo{}, err
+		}
+	}
+}
+
+func (tu *trackingUpdater) DeleteCryptoTracking(Symbol string) error {
+	m := tu.m
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.unpin(Symbol); err != nil {
+		return err
+	}
+	return tu.PriceUpdater.DeleteCryptoTracking(Symbol)
+}
+
+func (tu *trackingUpdater) DeleteCryptoTrackingByID(ID string) (priceSource.CoinInfo, error) {
+	m := tu.m
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	coin, ok, err := m.trackedCoin(func(c priceSource.CoinInfo) bool { return c.ID == ID })
+	if err != nil {
+		return priceSource.CoinInfo{}, err
+	}
+	if ok {
+		if err := m.unpin(coin.Symbol); err != nil {
+			return priceSource.CoinInfo{}, err
+		}
+	}
+	return tu.PriceUpdater.DeleteCryptoTrackingByID(ID)
+}
+
+// unpin drops symbol from the shared list before it is untracked, failing
+// when a watchlist still uses it. Callers hold m.mu.
+func (m *Manager) unpin(symbol string) error {
+	pinned := m.lists[pinnedID]
+	isPinned := contains(pinned.Symbols, symbol)
+	refs := m.refs[symbol]
+	if isPinned {
+		refs--
+	}
+	if refs > 0 {
+		return fmt.Errorf("%w: %s is in %d watchlists", ErrWatched, symbol, refs)
+	}
+	if !isPinned {
+		return nil
+	}
+	updated := copyList(pinned)
+	updated.Symbols = without(pinned.Symbols, symbol)
+	updated.UpdatedAt = m.now().UTC()
+	if err := m.store.SaveWatchlist(updated); err != nil {
+		return err
+	}
+	*pinned = updated
+	delete(m.refs, symbol)
+	return nil
+}
+
+// trackedCoin returns the tracked coin matching match. Callers hold m.mu.
+func (m *Manager) trackedCoin(match func(priceSource.CoinInfo) bool) (priceSource.CoinInfo, bool, error) {
+	coins, err := m.store.GetTrackedCoins()
+	if err != nil {
+		return priceSource.CoinInfo{}, false, err
+	}
+	for _, tc := range coins {
+		coin := priceSource.CoinInfo{ID: tc.ID, Symbol: tc.Symbol, Name: tc.Name}
+		if match(coin) {
+			return coin, true, nil
+		}
+	}
+	return priceSource.CoinInfo{}, false, nil
+}
@@ -0,0 +1,456 @@
+package watchlistManager
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/priceSource"
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+const (
+	defaultMaxPerUser = 20
+	defaultMaxSymbols = 100
+
+	// pinnedID is the list of coins tracked directly through /crypto. It has
+	// no user, so it never shows up among a user's watchlists.
+	pinnedID = "pinned"
+)
+
+var (
+	ErrInvalidWatchlist  = errors.New("invalid watchlist")
+	ErrTooManyWatchlists = errors.New("too many watchlists")
+	ErrWatchlistExists   = errors.New("watchlist already exists")
+	ErrNotInWatchlist    = errors.New("symbol is not in watchlist")
+	ErrWatched           = errors.New("coin is used by watchlists")
+)
+
+type Store interface {
+	storage.CryptoTracking
+	storage.Watchlists
+}
+
+// Manager keeps per-user watchlists and reference-counts their symbols: a
+// coin is tracked by the updater while at least one list, or the shared
+// list of coins added through /crypto, contains it, and is untracked with
+// its price history when the last one drops it. Lists and reference counts
+// are guarded by mu; coins are tracked with mu released, since that queries
+// the price provider.
+type Manager struct {
+	store   Store
+	updater priceUpdater.PriceUpdater
+	cfg     config.WatchlistsConfig
+	now     func() time.Time
+
+	mu    sync.Mutex
+	lists map[string]*storage.Watchlist
+	refs  map[string]int
+}
+
+func New(cfg *config.Config, store Store, updater priceUpdater.PriceUpdater) (*Manager, error) {
+	m := &Manager{
+		store:   store,
+		updater: updater,
+		cfg:     cfg.WatchlistsConfig,
+		now:     time.Now,
+		lists:   make(map[string]*storage.Watchlist),
+		refs:    make(map[string]int),
+	}
+	if m.cfg.MaxPerUser <= 0 {
+		m.cfg.MaxPerUser = defaultMaxPerUser
+	}
+	if m.cfg.MaxSymbols <= 0 {
+		m.cfg.MaxSymbols = defaultMaxSymbols
+	}
+	lists, err := store.GetWatchlists("")
+	if err != nil {
+		return nil, fmt.Errorf("load watchlists: %w", err)
+	}
+	for _, list := range lists {
+		m.lists[list.ID] = &list
+		for _, symbol := range list.Symbols {
+			m.refs[symbol]++
+		}
+	}
+	if _, ok := m.lists[pinnedID]; !ok {
+		if err := m.pinTracked(); err != nil {
+			return nil, fmt.Errorf("pin tracked coins: %w", err)
+		}
+	}
+	return m, nil
+}
+
+// pinTracked creates the shared list from the coins tracked before
+// watchlists existed, so that they stay tracked.
+func (m *Manager) pinTracked() error {
+	coins, err := m.store.GetTrackedCoins()
+	if err != nil {
+		return err
+	}
+	now := m.now().UTC()
+	pinned := storage.Watchlist{ID: pinnedID, Name: pinnedID, Symbols: []string{}, CreatedAt: now, UpdatedAt: now}
+	for _, coin := range coins {
+		if !contains(pinned.Symbols, coin.Symbol) {
+			pinned.Symbols = append(pinned.Symbols, coin.Symbol)
+		}
+	}
+	if err := m.store.SaveWatchlist(pinned); err != nil {
+		return err
+	}
+	m.lists[pinnedID] = &pinned
+	for _, symbol := range pinned.Symbols {
+		m.refs[symbol]++
+	}
+	return nil
+}
+
+func (m *Manager) Create(user, name string, symbols []string) (storage.Watchlist, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return storage.Watchlist{}, fmt.Errorf("%w: name is required", ErrInvalidWatchlist)
+	}
+	id, err := newID()
+	if err != nil {
+		return storage.Watchlist{}, err
+	}
+
+	tracked, err := m.lockTracking(symbols)
+	defer m.mu.Unlock()
+	defer m.drop(tracked)
+	if err != nil {
+		return storage.Watchlist{}, err
+	}
+	count := 0
+	for _, list := range m.lists {
+		if list.User == user {
+			count++
+		}
+	}
+	if count >= m.cfg.MaxPerUser {
+		return storage.Watchlist{}, fmt.Errorf("%w: limit is %d per user", ErrTooManyWatchlists, m.cfg.MaxPerUser)
+	}
+	if err := m.checkName(user, "", name); err != nil {
+		return storage.Watchlist{}, err
+	}
+	now := m.now().UTC()
+	list := &storage.Watchlist{ID: id, User: user, Name: name, CreatedAt: now}
+	if err := m.replace(list, name, symbols); err != nil {
+		return storage.Watchlist{}, err
+	}
+	m.lists[id] = list
+	return copyList(list), nil
+}
+
+func (m *Manager) Lists(user string) []storage.Watchlist {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := make([]storage.Watchlist, 0)
+	for _, list := range m.lists {
+		if list.User == user && user != "" {
+			res = append(res, copyList(list))
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].CreatedAt.Equal(res[j].CreatedAt) {
+			return res[i].CreatedAt.Before(res[j].CreatedAt)
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+func (m *Manager) Get(user, id string) (storage.Watchlist, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list, err := m.owned(user, id)
+	if err != nil {
+		return storage.Watchlist{}, err
+	}
+	return copyList(list), nil
+}
+
+// Update renames the list and replaces its symbols.
+func (m *Manager) Update(user, id, name string, symbols []string) (storage.Watchlist, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return storage.Watchlist{}, fmt.Errorf("%w: name is required", ErrInvalidWatchlist)
+	}
+	tracked, err := m.lockTracking(symbols)
+	defer m.mu.Unlock()
+	defer m.drop(tracked)
+	if err != nil {
+		return storage.Watchlist{}, err
+	}
+	list, err := m.owned(user, id)
+	if err != nil {
+		return storage.Watchlist{}, err
+	}
+	if err := m.checkName(user, id, name); err != nil {
+		return storage.Watchlist{}, err
+	}
+	if err := m.replace(list, name, symbols); err != nil {
+		return storage.Watchlist{}, err
+	}
+	return copyList(list), nil
+}
+
+func (m *Manager) AddSymbols(user, id string, symbols []string) (storage.Watchlist, error) {
+	tracked, err := m.lockTracking(symbols)
+	defer m.mu.Unlock()
+	defer m.drop(tracked)
+	if err != nil {
+		return storage.Watchlist{}, err
+	}
+	list, err := m.owned(user, id)
+	if err != nil {
+		return storage.Watchlist{}, err
+	}
+	if len(normalize(symbols)) == 0 {
+		return storage.Watchlist{}, fmt.Errorf("%w: symbols must not be empty", ErrInvalidWatchlist)
+	}
+	if err := m.replace(list, list.Name, append(append([]string{}, list.Symbols...), symbols...)); err != nil {
+		return storage.Watchlist{}, err
+	}
+	return copyList(list), nil
+}
+
+func (m *Manager) RemoveSymbol(user, id, symbol string) (storage.Watchlist, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list, err := m.owned(user, id)
+	if err != nil {
+		return storage.Watchlist{}, err
+	}
+	symbol = strings.ToLower(strings.TrimSpace(symbol))
+	if !contains(list.Symbols, symbol) {
+		return storage.Watchlist{}, fmt.Errorf("%w: %s", ErrNotInWatchlist, symbol)
+	}
+	if err := m.replace(list, list.Name, without(list.Symbols, symbol)); err != nil {
+		return storage.Watchlist{}, err
+	}
+	return copyList(list), nil
+}
+
+func (m *Manager) Delete(user, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list, err := m.owned(user, id)
+	if err != nil {
+		return err
+	}
+	if err := m.store.DeleteWatchlist(user, id); err != nil {
+		return err
+	}
+	delete(m.lists, id)
+	for _, symbol := range list.Symbols {
+		m.release(symbol)
+	}
+	return nil
+}
+
+// owned returns the list id of user. Callers hold m.mu.
+func (m *Manager) owned(user, id string) (*storage.Watchlist, error) {
+	list, ok := m.lists[id]
+	if !ok || list.User != user || user == "" {
+		return nil, storage.ErrWatchlistNotExists
+	}
+	return list, nil
+}
+
+// checkName rejects a name already used by another list of user. Callers
+// hold m.mu.
+func (m *Manager) checkName(user, id, name string) error {
+	for _, list := range m.lists {
+		if list.User == user && list.ID != id && strings.EqualFold(list.Name, name) {
+			return fmt.Errorf("%w: %s", ErrWatchlistExists, name)
+		}
+	}
+	return nil
+}
+
+// replace stores list with the given name and symbols, referencing added
+// symbols first and releasing removed ones after the list is saved. Added
+// symbols must already be tracked, see lockTracking. On failure list is left
+// unchanged. Callers hold m.mu.
+func (m *Manager) replace(list *storage.Watchlist, name string, symbols []string) error {
+	symbols = normalize(symbols)
+	if list.ID != pinnedID && len(symbols) > m.cfg.MaxSymbols {
+		return fmt.Errorf("%w: at most %d symbols per watchlist", ErrInvalidWatchlist, m.cfg.MaxSymbols)
+	}
+	var added []string
+	for _, symbol := range symbols {
+		if contains(list.Symbols, symbol) {
+			continue
+		}
+		m.refs[symbol]++
+		added = append(added, symbol)
+	}
+
+	updated := copyList(list)
+	updated.Name = name
+	updated.Symbols = symbols
+	updated.UpdatedAt = m.now().UTC()
+	if err := m.store.SaveWatchlist(updated); err != nil {
+		for _, s := range added {
+			m.release(s)
+		}
+		return err
+	}
+	removed := list.Symbols
+	*list = updated
+	for _, symbol := range removed {
+		if !contains(symbols, symbol) {
+			m.release(symbol)
+		}
+	}
+	return nil
+}
+
+// lockTracking locks m.mu once every symbol is referenced or tracked.
+// Untracked symbols are tracked with m.mu released, since that queries the
+// price provider, and the check is repeated after locking again in case a
+// concurrent release untracked one meanwhile. It returns with m.mu held,
+// also on error, along with the symbols it tracked, which the caller passes
+// to drop once done.
+func (m *Manager) lockTracking(symbols []string) ([]string, error) {
+	symbols = normalize(symbols)
+	var tracked []string
+	m.mu.Lock()
+	if len(symbols) > m.cfg.MaxSymbols {
+		return nil, fmt.Errorf("%w: at most %d symbols per watchlist", ErrInvalidWatchlist, m.cfg.MaxSymbols)
+	}
+	for {
+		missing, err := m.untracked(symbols)
+		if err != nil || len(missing) == 0 {
+			return tracked, err
+		}
+		m.mu.Unlock()
+		for _, symbol := range missing {
+			_, err = m.updater.AddCryptoTracking(symbol)
+			if err == nil {
+				tracked = append(tracked, symbol)
+				continue
+			}
+			if errors.Is(err, priceUpdater.ErrAlreadyTracked) {
+				err = nil
+				continue
+			}
+			if errors.Is(err, priceSource.ErrUnknownCoin) {
+				err = fmt.Errorf("%w: %s", priceSource.ErrUnknownCoin, symbol)
+			}
+			break
+		}
+		m.mu.Lock()
+		if err != nil {
+			return tracked, err
+		}
+	}
+}
+
+// untracked returns the symbols that no list references and the updater
+// does not track. Callers hold m.mu.
+func (m *Manager) untracked(symbols []string) ([]string, error) {
+	coins, err := m.store.GetTrackedCoins()
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, symbol := range symbols {
+		if m.refs[symbol] > 0 {
+			continue
+		}
+		found := false
+		for _, coin := range coins {
+			if coin.Symbol == symbol {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = append(res, symbol)
+		}
+	}
+	return res, nil
+}
+
+// drop untracks the symbols tracked by lockTracking that ended up in no
+// list. Callers hold m.mu.
+func (m *Manager) drop(tracked []string) {
+	for _, symbol := range tracked {
+		if m.refs[symbol] == 0 {
+			m.untrack(symbol)
+		}
+	}
+}
+
+// release drops a reference to symbol and untracks it, deleting its price
+// history, when none is left. Callers hold m.mu.
+func (m *Manager) release(symbol string) {
+	if m.refs[symbol]--; m.refs[symbol] > 0 {
+		return
+	}
+	delete(m.refs, symbol)
+	m.untrack(symbol)
+}
+
+func (m *Manager) untrack(symbol string) {
+	if err := m.updater.DeleteCryptoTracking(symbol); err != nil {
+		log.Printf("watchlists: untrack %s: %v", symbol, err)
+		return
+	}
+	if err := m.store.DeleteCrypto(symbol); err != nil && !errors.Is(err, storage.ErrCryptoNotExists) {
+		log.Printf("watchlists: delete %s history: %v", symbol, err)
+	}
+}
+
+func normalize(symbols []string) []string {
+	res := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		if symbol = strings.ToLower(strings.TrimSpace(symbol)); symbol != "" && !contains(res, symbol) {
+			res = append(res, symbol)
+		}
+	}
+	return res
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func without(values []string, value string) []string {
+	res := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != value {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func copyList(list *storage.Watchlist) storage.Watchlist {
+	res := *list
+	res.Symbols = append([]string{}, list.Symbols...)
+	return res
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,321 @@
+package watchlistManager
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/priceSource"
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
+	"github.com/zenrot/CryptoService/internal/storage"
+	"github.com/zenrot/CryptoService/internal/storage/ramstore"
+)
+
+var coins = map[string]priceSource.CoinInfo{
+	"btc": {ID: "bitcoin", Symbol: "btc", Name: "Bitcoin"},
+	"eth": {ID: "ethereum", Symbol: "eth", Name: "Ethereum"},
+	"sol": {ID: "solana", Symbol: "sol", Name: "Solana"},
+}
+
+// fakeUpdater tracks coins in the store the way the real updater does and
+// stores a price for each added coin. With block set, adding a coin signals
+// entered and waits for block to be closed, like a slow provider lookup.
+type fakeUpdater struct {
+	priceUpdater.PriceUpdater
+	store   Store
+	adds    map[string]int
+	entered chan struct{}
+	block   chan struct{}
+}
+
+func (fu *fakeUpdater) tracked(symbol string) bool {
+	tracked, _ := fu.store.GetTrackedCoins()
+	for _, coin := range tracked {
+		if coin.Symbol == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+func (fu *fakeUpdater) AddCryptoTracking(Symbol string) (priceSource.CoinInfo, error) {
+	if fu.block != nil {
+		fu.entered <- struct{}{}
+		<-fu.block
+	}
+	if fu.tracked(Symbol) {
+		return priceSource.CoinInfo{}, fmt.Errorf("%w: %s", priceUpdater.ErrAlreadyTracked, Symbol)
+	}
+	coin, ok := coins[Symbol]
+	if !ok {
+		return priceSource.CoinInfo{}, fmt.Errorf("%w: %s", priceSource.ErrUnknownCoin, Symbol)
+	}
+	fu.adds[Symbol]++
+	if err := fu.store.SaveTrackedCoin(storage.TrackedCoin{ID: coin.ID, Symbol: coin.Symbol, Name: coin.Name, Enabled: true}); err != nil {
+		return priceSource.CoinInfo{}, err
+	}
+	return coin, fu.store.AddCrypto(coin.Symbol, coin.Name, "usd", 1, time.Now())
+}
+
+func (fu *fakeUpdater) AddCryptoTrackingByID(ID string) (priceSource.CoinInfo, error) {
+	for symbol, coin := range coins {
+		if coin.ID == ID {
+			return fu.AddCryptoTracking(symbol)
+		}
+	}
+	return priceSource.CoinInfo{}, fmt.Errorf("%w: %s", priceSource.ErrUnknownCoin, ID)
+}
+
+func (fu *fakeUpdater) DeleteCryptoTracking(Symbol string) error {
+	if !fu.tracked(Symbol) {
+		return fmt.Errorf("symbol %s is %w", Symbol, priceUpdater.ErrNotTracked)
+	}
+	return fu.store.DeleteTrackedCoin(coins[Symbol].ID)
+}
+
+func (fu *fakeUpdater) DeleteCryptoTrackingByID(ID string) (priceSource.CoinInfo, error) {
+	for symbol, coin := range coins {
+		if coin.ID == ID {
+			return coin, fu.DeleteCryptoTracking(symbol)
+		}
+	}
+	return priceSource.CoinInfo{}, fmt.Errorf("coin %s is %w", ID, priceUpdater.ErrNotTracked)
+}
+
+func newTestManager(t *testing.T, cfg config.WatchlistsConfig) (*Manager, *fakeUpdater) {
+	t.Helper()
+	store, err := ramstore.NewRamStorage(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	updater := &fakeUpdater{store: store, adds: make(map[string]int)}
+	m, err := New(&config.Config{WatchlistsConfig: cfg}, store, updater)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, updater
+}
+
+func trackedSymbols(t *testing.T, store Store) string {
+	t.Helper()
+	tracked, err := store.GetTrackedCoins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbols := make([]string, 0, len(tracked))
+	for _, coin := range tracked {
+		symbols = append(symbols, coin.Symbol)
+	}
+	sort.Strings(symbols)
+	return fmt.Sprint(symbols)
+}
+
+func TestReferenceCounting(t *testing.T) {
+	m, updater := newTestManager(t, config.WatchlistsConfig{})
+	alice, err := m.Create("alice", "majors", []string{"BTC", " eth", "btc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(alice.Symbols) != "[btc eth]" {
+		t.Fatalf("symbols not normalized: %v", alice.Symbols)
+	}
+	bob, err := m.Create("bob", "majors", []string{"btc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := trackedSymbols(t, m.store); got != "[btc eth]" || updater.adds["btc"] != 1 {
+		t.Fatalf("tracked %s, btc added %d times", got, updater.adds["btc"])
+	}
+
+	if _, err := m.RemoveSymbol("alice", alice.ID, "btc"); err != nil {
+		t.Fatal(err)
+	}
+	if got := trackedSymbols(t, m.store); got != "[btc eth]" {
+		t.Fatalf("btc untracked while bob still watches it: %s", got)
+	}
+	if err := m.Delete("bob", bob.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := trackedSymbols(t, m.store); got != "[eth]" {
+		t.Fatalf("btc still tracked after its last list dropped it: %s", got)
+	}
+	if latest, _ := m.store.GetLatestCrypto("usd"); len(latest) != 1 {
+		t.Fatalf("btc history not deleted: %+v", latest)
+	}
+
+	if _, err := m.AddSymbols("alice", alice.ID, []string{"sol", "doge"}); !errors.Is(err, priceSource.ErrUnknownCoin) {
+		t.Fatalf("got %v, want ErrUnknownCoin", err)
+	}
+	if got := trackedSymbols(t, m.store); got != "[eth]" {
+		t.Fatalf("failed add left coins tracked: %s", got)
+	}
+	if list, _ := m.Get("alice", alice.ID); fmt.Sprint(list.Symbols) != "[eth]" {
+		t.Fatalf("failed add changed the list: %v", list.Symbols)
+	}
+
+	list, err := m.Update("alice", alice.ID, "alts", []string{"sol"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Name != "alts" || trackedSymbols(t, m.store) != "[sol]" {
+		t.Fatalf("unexpected update result %+v, tracked %s", list, trackedSymbols(t, m.store))
+	}
+
+	restarted, err := New(&config.Config{}, m.store, updater)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restarted.refs["sol"] != 1 || restarted.refs["btc"] != 0 {
+		t.Fatalf("unexpected refs after restart %v", restarted.refs)
+	}
+	if err := restarted.Delete("alice", alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := trackedSymbols(t, m.store); got != "[]" {
+		t.Fatalf("sol still tracked after restart and delete: %s", got)
+	}
+}
+
+func TestTrackingUpdater(t *testing.T) {
+	store, err := ramstore.NewRamStorage(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	updater := &fakeUpdater{store: store, adds: make(map[string]int)}
+	if _, err := updater.AddCryptoTracking("eth"); err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(&config.Config{}, store, updater)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracking := m.Tracking()
+
+	list, err := m.Create("alice", "mine", []string{"btc", "eth"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Update("alice", list.ID, "mine", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := trackedSymbols(t, store); got != "[eth]" {
+		t.Fatalf("coin tracked before watchlists existed was untracked: %s", got)
+	}
+
+	if _, err := m.AddSymbols("alice", list.ID, []string{"btc"}); err != nil {
+		t.Fatal(err)
+	}
+	coin, err := tracking.AddCryptoTracking("btc")
+	if err != nil || coin.ID != "bitcoin" {
+		t.Fatalf("pin watched coin: %+v, %v", coin, err)
+	}
+	if _, err := tracking.AddCryptoTrackingByID("bitcoin"); !errors.Is(err, priceUpdater.ErrAlreadyTracked) {
+		t.Fatalf("second pin: got %v, want already exists", err)
+	}
+	if err := tracking.DeleteCryptoTracking("btc"); !errors.Is(err, ErrWatched) {
+		t.Fatalf("delete watched coin: got %v, want ErrWatched", err)
+	}
+	if _, err := m.RemoveSymbol("alice", list.ID, "btc"); err != nil {
+		t.Fatal(err)
+	}
+	if got := trackedSymbols(t, store); got != "[btc eth]" {
+		t.Fatalf("pinned coin untracked with its last watchlist: %s", got)
+	}
+	if _, err := tracking.DeleteCryptoTrackingByID("bitcoin"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracking.DeleteCryptoTracking("eth"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracking.DeleteCryptoTracking("eth"); err == nil {
+		t.Fatal("deleting an untracked coin succeeded")
+	}
+	if got := trackedSymbols(t, store); got != "[]" {
+		t.Fatalf("unpinned coins still tracked: %s", got)
+	}
+	if lists := m.Lists(""); len(lists) != 0 {
+		t.Fatalf("shared list exposed: %+v", lists)
+	}
+}
+
+func TestWatchlistValidation(t *testing.T) {
+	m, _ := newTestManager(t, config.WatchlistsConfig{MaxPerUser: 2, MaxSymbols: 2})
+	list, err := m.Create("alice", "one", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Create("alice", " ONE ", nil); !errors.Is(err, ErrWatchlistExists) {
+		t.Fatalf("duplicate name: got %v, want ErrWatchlistExists", err)
+	}
+	if _, err := m.Create("alice", " ", nil); !errors.Is(err, ErrInvalidWatchlist) {
+		t.Fatalf("empty name: got %v, want ErrInvalidWatchlist", err)
+	}
+	if _, err := m.Create("alice", "many", []string{"btc", "eth", "sol"}); !errors.Is(err, ErrInvalidWatchlist) {
+		t.Fatalf("too many symbols: got %v, want ErrInvalidWatchlist", err)
+	}
+	if _, err := m.Create("alice", "two", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Create("alice", "three", nil); !errors.Is(err, ErrTooManyWatchlists) {
+		t.Fatalf("got %v, want ErrTooManyWatchlists", err)
+	}
+	if _, err := m.Create("bob", "one", nil); err != nil {
+		t.Fatalf("names are per user: %v", err)
+	}
+	if _, err := m.Get("bob", list.ID); !errors.Is(err, storage.ErrWatchlistNotExists) {
+		t.Fatalf("foreign get: got %v, want ErrWatchlistNotExists", err)
+	}
+	if _, err := m.Get("", pinnedID); !errors.Is(err, storage.ErrWatchlistNotExists) {
+		t.Fatalf("shared list get: got %v, want ErrWatchlistNotExists", err)
+	}
+	if _, err := m.RemoveSymbol("alice", list.ID, "btc"); !errors.Is(err, ErrNotInWatchlist) {
+		t.Fatalf("got %v, want ErrNotInWatchlist", err)
+	}
+	if err := m.Delete("bob", list.ID); !errors.Is(err, storage.ErrWatchlistNotExists) {
+		t.Fatalf("foreign delete: got %v, want ErrWatchlistNotExists", err)
+	}
+}
+
+func TestTrackingOutsideLock(t *testing.T) {
+	m, updater := newTestManager(t, config.WatchlistsConfig{})
+	list, err := m.Create("alice", "main", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updater.entered = make(chan struct{})
+	updater.block = make(chan struct{})
+	added := make(chan error, 1)
+	go func() {
+		_, err := m.AddSymbols("alice", list.ID, []string{"btc"})
+		added <- err
+	}()
+	<-updater.entered
+
+	done := make(chan error, 1)
+	go func() {
+		m.Lists("alice")
+		_, err := m.Create("bob", "main", nil)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchlist calls waited for the provider lookup")
+	}
+
+	close(updater.block)
+	if err := <-added; err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := m.Get("alice", list.ID); fmt.Sprint(got.Symbols) != "[btc]" {
+		t.Fatalf("got symbols %v, want [btc]", got.Symbols)
+	}
+}
@@ -0,0 +1,197 @@
+package webhookDispatcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderSignature = "X-Webhook-Signature"
+
+	signaturePrefix = "sha256="
+	maxErrorBody    = 512
+	maxDrainBody    = 4 << 10
+)
+
+// Sign returns the X-Webhook-Signature value for body: the hex HMAC-SHA256 of
+// the raw request body keyed with the webhook secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid Sign result for body.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+func (d *Dispatcher) run(ctx context.Context, stop <-chan struct{}) {
+	defer close(d.done)
+	poll := time.NewTicker(d.cfg.PollInterval)
+	defer poll.Stop()
+	health := time.NewTicker(d.cfg.HealthInterval)
+	defer health.Stop()
+	prune := time.NewTicker(pruneInterval)
+	defer prune.Stop()
+
+	d.Flush(ctx)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+			d.Flush(ctx)
+		case <-poll.C:
+			d.Flush(ctx)
+		case <-health.C:
+			d.CheckHealth()
+		case <-prune.C:
+			if err := d.store.PruneDeliveries(d.now().Add(-d.cfg.Retention)); err != nil {
+				log.Println("webhooks: prune deliveries:", err)
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) notifyWorker() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Flush sends every delivery that is due, in batches of up to Workers
+// concurrent requests, until none is left, a batch records no progress or ctx
+// is done.
+func (d *Dispatcher) Flush(ctx context.Context) {
+	batch := d.cfg.Workers * 4
+	for ctx.Err() == nil {
+		due, err := d.store.GetDueDeliveries(d.now(), batch)
+		if err != nil {
+			log.Println("webhooks: load deliveries:", err)
+			return
+		}
+		if len(due) == 0 {
+			return
+		}
+		sem := make(chan struct{}, d.cfg.Workers)
+		var wg sync.WaitGroup
+		var saved atomic.Int32
+		for _, delivery := range due {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				if d.deliver(ctx, delivery) {
+					saved.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		if len(due) < batch || saved.Load() == 0 {
+			return
+		}
+	}
+}
+
+// deliver makes one attempt at delivery and reports whether its new state was
+// stored. Deliveries of a webhook that no longer exists are marked dead.
+func (d *Dispatcher) deliver(ctx context.Context, delivery storage.WebhookDelivery) bool {
+	hook, ok := d.webhook(delivery.WebhookID)
+	if !ok {
+		delivery.Status = storage.DeliveryDead
+		delivery.LastError = storage.ErrWebhookNotExists.Error()
+		delivery.UpdatedAt = d.now().UTC()
+		return d.save(delivery)
+	}
+	code, err := d.send(ctx, hook, delivery)
+	if ctx.Err() != nil {
+		return false
+	}
+
+	now := d.now().UTC()
+	delivery.Attempts++
+	delivery.ResponseCode = code
+	delivery.UpdatedAt = now
+	switch {
+	case err == nil:
+		delivery.Status = storage.DeliveryDelivered
+		delivery.LastError = ""
+	case delivery.Attempts >= d.cfg.MaxAttempts:
+		delivery.Status = storage.DeliveryDead
+		delivery.LastError = err.Error()
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttempt = now.Add(d.backoff(delivery.Attempts))
+	}
+	return d.save(delivery)
+}
+
+func (d *Dispatcher) save(delivery storage.WebhookDelivery) bool {
+	err := d.store.SaveDelivery(delivery)
+	if err != nil && !errors.Is(err, storage.ErrWebhookNotExists) {
+		log.Printf("webhooks: delivery %s: %v", delivery.ID, err)
+		return false
+	}
+	return true
+}
+
+func (d *Dispatcher) send(ctx context.Context, hook storage.Webhook, delivery storage.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CryptoService-Webhook")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderSignature, Sign(hook.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if len(msg) > 0 {
+			return resp.StatusCode, fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+		}
+		return resp.StatusCode, errors.New(resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff is the delay before the attempt following the given number of
+// failed ones: BackoffBase doubled per failure, capped at BackoffMax.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.BackoffBase
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.cfg.BackoffMax {
+			return d.cfg.BackoffMax
+		}
+	}
+	return min(delay, d.cfg.BackoffMax)
+}
@@ -0,0 +1,196 @@
+package webhookDispatcher
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+type Payload struct {
+	ID    string    `json:"id"`
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+	Data  any       `json:"data"`
+}
+
+type PriceData struct {
+	Symbol   string    `json:"symbol"`
+	Name     string    `json:"name"`
+	Currency string    `json:"currency"`
+	Price    float64   `json:"price"`
+	Time     time.Time `json:"time"`
+}
+
+type CoinData struct {
+	ID     string `json:"id"`
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+}
+
+type FeedData struct {
+	Symbol              string    `json:"symbol"`
+	Status              string    `json:"status"`
+	LastError           string    `json:"last_error"`
+	LastSuccess         time.Time `json:"last_success"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+}
+
+// Notify queues a delivery of event to every webhook subscribed to it and to
+// symbol. It runs on the caller's goroutine, which for prices is the updater,
+// and costs one SaveDelivery per matching webhook: the delivery is stored
+// before Notify returns so that it survives a restart, and only sending is
+// left to the background loop. BenchmarkNotify measures this cost.
+func (d *Dispatcher) Notify(event, symbol string, data any) {
+	d.mu.RLock()
+	var hooks []storage.Webhook
+	for _, hook := range d.hooks {
+		if contains(hook.Events, event) && (len(hook.Symbols) == 0 || contains(hook.Symbols, symbol)) {
+			hooks = append(hooks, hook)
+		}
+	}
+	d.mu.RUnlock()
+	if len(hooks) == 0 {
+		return
+	}
+
+	now := d.now().UTC()
+	for _, hook := range hooks {
+		id, err := randomHex(8)
+		if err != nil {
+			log.Println("webhooks:", err)
+			continue
+		}
+		body, err := json.Marshal(Payload{ID: id, Event: event, Time: now, Data: data})
+		if err != nil {
+			log.Println("webhooks:", err)
+			continue
+		}
+		err = d.store.SaveDelivery(storage.WebhookDelivery{
+			ID:          id,
+			WebhookID:   hook.ID,
+			Event:       event,
+			Payload:     string(body),
+			Status:      storage.DeliveryPending,
+			NextAttempt: now,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+		if err != nil {
+			log.Printf("webhooks: queue %s for %s: %v", event, hook.ID, err)
+		}
+	}
+	d.notifyWorker()
+}
+
+// CheckHealth emits feed.failing when a symbol's feed starts failing and
+// feed.recovered when it succeeds again.
+func (d *Dispatcher) CheckHealth() {
+	if d.health == nil {
+		return
+	}
+	healths := d.health.GetHealths()
+	for symbol, h := range healths {
+		failing := h.Status == priceUpdater.StatusFailing
+		if failing == d.failing[symbol] {
+			continue
+		}
+		data := FeedData{
+			Symbol:              symbol,
+			Status:              h.Status,
+			LastError:           h.LastError,
+			LastSuccess:         h.LastSuccess,
+			ConsecutiveFailures: h.ConsecutiveFailures,
+		}
+		if failing {
+			d.Notify(EventFeedFailing, symbol, data)
+			d.failing[symbol] = true
+		} else {
+			d.Notify(EventFeedRecovered, symbol, data)
+			delete(d.failing, symbol)
+		}
+	}
+	for symbol := range d.failing {
+		if _, ok := healths[symbol]; !ok {
+			delete(d.failing, symbol)
+		}
+	}
+}
+
+type notifyingStore struct {
+	storage.CryptoTracking
+	dispatcher *Dispatcher
+
+	mu    sync.Mutex
+	coins map[string]storage.TrackedCoin
+}
+
+// Notifying returns store with stored prices and coins added to or removed
+// from tracking also notified to webhooks.
+func (d *Dispatcher) Notifying(store storage.CryptoTracking) storage.CryptoTracking {
+	return &notifyingStore{CryptoTracking: store, dispatcher: d}
+}
+
+func (ns *notifyingStore) AddCrypto(symbol, name, currency string, price float64, t time.Time) error {
+	if err := ns.CryptoTracking.AddCrypto(symbol, name, currency, price, t); err != nil {
+		return err
+	}
+	ns.dispatcher.Notify(EventPriceUpdated, symbol,
+		PriceData{Symbol: symbol, Name: name, Currency: currency, Price: price, Time: t})
+	return nil
+}
+
+// loadCoins remembers the coins tracked before the first change so that
+// schedule updates of known coins are not reported as additions. Callers hold
+// ns.mu.
+func (ns *notifyingStore) loadCoins() error {
+	if ns.coins != nil {
+		return nil
+	}
+	coins, err := ns.CryptoTracking.GetTrackedCoins()
+	if err != nil {
+		return err
+	}
+	ns.coins = make(map[string]storage.TrackedCoin, len(coins))
+	for _, coin := range coins {
+		ns.coins[coin.ID] = coin
+	}
+	return nil
+}
+
+func (ns *notifyingStore) SaveTrackedCoin(coin storage.TrackedCoin) error {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if err := ns.loadCoins(); err != nil {
+		return err
+	}
+	if err := ns.CryptoTracking.SaveTrackedCoin(coin); err != nil {
+		return err
+	}
+	_, known := ns.coins[coin.ID]
+	ns.coins[coin.ID] = coin
+	if !known {
+		ns.dispatcher.Notify(EventCoinAdded, coin.Symbol, CoinData{ID: coin.ID, Symbol: coin.Symbol, Name: coin.Name})
+	}
+	return nil
+}
+
+func (ns *notifyingStore) DeleteTrackedCoin(id string) error {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if err := ns.loadCoins(); err != nil {
+		return err
+	}
+	if err := ns.CryptoTracking.DeleteTrackedCoin(id); err != nil {
+		return err
+	}
+	coin, known := ns.coins[id]
+	delete(ns.coins, id)
+	if known {
+		ns.dispatcher.Notify(EventCoinDeleted, coin.Symbol, CoinData{ID: coin.ID, Symbol: coin.Symbol, Name: coin.Name})
+	}
+	return nil
+}
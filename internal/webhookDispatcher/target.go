@@ -0,0 +1,69 @@
+package webhookDispatcher
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+var errForbiddenTarget = errors.New("webhook target address is not allowed")
+
+// sharedAddressSpace is the RFC 6598 carrier-grade NAT range, which some
+// clouds use for their metadata services.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// forbidden reports whether ip is an internal destination webhooks must not
+// reach: loopback, private, link-local (including 169.254.169.254),
+// multicast, unspecified or shared address space.
+func forbidden(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip)
+}
+
+// checkHost rejects a webhook URL host that is a literal forbidden IP.
+// Hostnames are checked at dial time, after resolution.
+func checkHost(host string) error {
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return nil
+	}
+	if forbidden(ip) {
+		return errForbiddenTarget
+	}
+	return nil
+}
+
+// guardDial is a net.Dialer Control that refuses connections to forbidden
+// addresses. It sees the resolved IP, so DNS names pointing inside the
+// network are caught as well.
+func guardDial(network, address string, _ syscall.RawConn) error {
+	addr, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errForbiddenTarget, address)
+	}
+	if forbidden(addr.Addr()) {
+		return fmt.Errorf("%w: %s", errForbiddenTarget, addr.Addr())
+	}
+	return nil
+}
+
+// newClient returns the delivery client. Unless allowPrivate is set, it
+// refuses internal destinations and ignores proxy settings, since a proxy
+// would make the dialed address differ from the target.
+func newClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	if allowPrivate {
+		return &http.Client{Timeout: timeout}
+	}
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second, Control: guardDial}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
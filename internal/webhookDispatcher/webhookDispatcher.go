@@ -0,0 +1,291 @@
+package webhookDispatcher
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
+	"github.com/zenrot/CryptoService/internal/storage"
+)
+
+const (
+	EventPriceUpdated  = "price.updated"
+	EventCoinAdded     = "coin.added"
+	EventCoinDeleted   = "coin.deleted"
+	EventFeedFailing   = "feed.failing"
+	EventFeedRecovered = "feed.recovered"
+)
+
+var Events = []string{EventPriceUpdated, EventCoinAdded, EventCoinDeleted, EventFeedFailing, EventFeedRecovered}
+
+const (
+	defaultWorkers        = 4
+	defaultTimeout        = 10 * time.Second
+	defaultMaxAttempts    = 8
+	defaultBackoffBase    = 5 * time.Second
+	defaultBackoffMax     = time.Hour
+	defaultPollInterval   = time.Second
+	defaultHealthInterval = 10 * time.Second
+	defaultRetention      = 7 * 24 * time.Hour
+	defaultMaxPerUser     = 20
+	pruneInterval         = time.Hour
+)
+
+var (
+	ErrInvalidWebhook  = errors.New("invalid webhook")
+	ErrTooManyWebhooks = errors.New("too many webhooks")
+)
+
+// HealthSource reports per-symbol feed health; the dispatcher polls it to
+// emit feed.failing and feed.recovered.
+type HealthSource interface {
+	GetHealths() map[string]priceUpdater.Health
+}
+
+// Dispatcher turns price and tracking events into signed webhook deliveries.
+// Deliveries are queued in the store first and sent by a background loop, so
+// pending ones survive restarts; failed attempts are retried with exponential
+// backoff and marked dead after MaxAttempts.
+type Dispatcher struct {
+	store  storage.Webhooks
+	cfg    config.WebhooksConfig
+	client *http.Client
+	now    func() time.Time
+
+	mu    sync.RWMutex
+	hooks map[string]storage.Webhook
+
+	health  HealthSource
+	failing map[string]bool
+
+	wake   chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+	cancel context.CancelFunc
+}
+
+func New(cfg *config.Config, store storage.Webhooks) (*Dispatcher, error) {
+	d := &Dispatcher{
+		store:   store,
+		cfg:     cfg.WebhooksConfig,
+		now:     time.Now,
+		hooks:   make(map[string]storage.Webhook),
+		failing: make(map[string]bool),
+		wake:    make(chan struct{}, 1),
+	}
+	if d.cfg.Workers <= 0 {
+		d.cfg.Workers = defaultWorkers
+	}
+	if d.cfg.Timeout <= 0 {
+		d.cfg.Timeout = defaultTimeout
+	}
+	if d.cfg.MaxAttempts <= 0 {
+		d.cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if d.cfg.BackoffBase <= 0 {
+		d.cfg.BackoffBase = defaultBackoffBase
+	}
+	if d.cfg.BackoffMax <= 0 {
+		d.cfg.BackoffMax = defaultBackoffMax
+	}
+	if d.cfg.PollInterval <= 0 {
+		d.cfg.PollInterval = defaultPollInterval
+	}
+	if d.cfg.HealthInterval <= 0 {
+		d.cfg.HealthInterval = defaultHealthInterval
+	}
+	if d.cfg.Retention <= 0 {
+		d.cfg.Retention = defaultRetention
+	}
+	if d.cfg.MaxPerUser <= 0 {
+		d.cfg.MaxPerUser = defaultMaxPerUser
+	}
+	d.client = newClient(d.cfg.Timeout, d.cfg.AllowPrivate)
+
+	hooks, err := store.GetWebhooks("")
+	if err != nil {
+		return nil, fmt.Errorf("load webhooks: %w", err)
+	}
+	for _, hook := range hooks {
+		d.hooks[hook.ID] = hook
+	}
+	return d, nil
+}
+
+// WatchHealth makes the dispatcher poll source for feed failures. It must be
+// called before Start.
+func (d *Dispatcher) WatchHealth(source HealthSource) {
+	d.health = source
+}
+
+func (d *Dispatcher) Start(ctx context.Context) error {
+	ctx, d.cancel = context.WithCancel(ctx)
+	d.stop = make(chan struct{})
+	d.done = make(chan struct{})
+	go d.run(ctx, d.stop)
+	return nil
+}
+
+// Shutdown stops the delivery loop. Requests in flight are cancelled and their
+// deliveries stay pending for the next start.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	if d.stop == nil {
+		return nil
+	}
+	close(d.stop)
+	d.cancel()
+	d.stop = nil
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Create validates hook, fills in the ID, creation time and, when empty, a
+// random secret, and stores it. Empty Events subscribes to every event and
+// empty Symbols to every symbol.
+func (d *Dispatcher) Create(hook storage.Webhook) (storage.Webhook, error) {
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return storage.Webhook{}, fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	if !d.cfg.AllowPrivate {
+		if err := checkHost(u.Hostname()); err != nil {
+			return storage.Webhook{}, fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+		}
+	}
+	if len(hook.Events) == 0 {
+		hook.Events = Events
+	}
+	events, err := normalize(hook.Events)
+	if err != nil {
+		return storage.Webhook{}, err
+	}
+	hook.Events = events
+	symbols := make([]string, 0, len(hook.Symbols))
+	for _, symbol := range hook.Symbols {
+		if symbol = strings.ToLower(strings.TrimSpace(symbol)); symbol != "" && !contains(symbols, symbol) {
+			symbols = append(symbols, symbol)
+		}
+	}
+	hook.Symbols = symbols
+	if hook.Secret == "" {
+		if hook.Secret, err = randomHex(32); err != nil {
+			return storage.Webhook{}, err
+		}
+	}
+	if hook.ID, err = randomHex(8); err != nil {
+		return storage.Webhook{}, err
+	}
+	hook.CreatedAt = d.now().UTC()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	count := 0
+	for _, h := range d.hooks {
+		if h.User == hook.User {
+			count++
+		}
+	}
+	if count >= d.cfg.MaxPerUser {
+		return storage.Webhook{}, fmt.Errorf("%w: limit is %d per user", ErrTooManyWebhooks, d.cfg.MaxPerUser)
+	}
+	if err := d.store.SaveWebhook(hook); err != nil {
+		return storage.Webhook{}, err
+	}
+	d.hooks[hook.ID] = hook
+	return hook, nil
+}
+
+func normalize(events []string) ([]string, error) {
+	res := make([]string, 0, len(events))
+	for _, event := range events {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if !contains(Events, event) {
+			return nil, fmt.Errorf("%w: unknown event %q, expected one of %s", ErrInvalidWebhook, event, strings.Join(Events, ", "))
+		}
+		if !contains(res, event) {
+			res = append(res, event)
+		}
+	}
+	return res, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (d *Dispatcher) Webhooks(user string) []storage.Webhook {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	res := make([]storage.Webhook, 0)
+	for _, hook := range d.hooks {
+		if hook.User == user {
+			res = append(res, hook)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].CreatedAt.Equal(res[j].CreatedAt) {
+			return res[i].CreatedAt.Before(res[j].CreatedAt)
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+func (d *Dispatcher) Delete(user, id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if hook, ok := d.hooks[id]; !ok || hook.User != user {
+		return storage.ErrWebhookNotExists
+	}
+	if err := d.store.DeleteWebhook(user, id); err != nil {
+		return err
+	}
+	delete(d.hooks, id)
+	return nil
+}
+
+// Deliveries returns the delivery log of a webhook owned by user, newest
+// first.
+func (d *Dispatcher) Deliveries(user, id, status string, limit int) ([]storage.WebhookDelivery, error) {
+	d.mu.RLock()
+	hook, ok := d.hooks[id]
+	d.mu.RUnlock()
+	if !ok || hook.User != user {
+		return nil, storage.ErrWebhookNotExists
+	}
+	return d.store.GetDeliveries(id, status, limit)
+}
+
+func (d *Dispatcher) webhook(id string) (storage.Webhook, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	hook, ok := d.hooks[id]
+	return hook, ok
+}
@@ -0,0 +1,358 @@
+package webhookDispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zenrot/CryptoService/internal/config"
+	"github.com/zenrot/CryptoService/internal/priceUpdater"
+	"github.com/zenrot/CryptoService/internal/storage"
+	"github.com/zenrot/CryptoService/internal/storage/ramstore"
+	"github.com/zenrot/CryptoService/internal/storage/sqliteStorage"
+)
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+func receiver(t *testing.T, status int) (*httptest.Server, chan received) {
+	t.Helper()
+	ch := make(chan received, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ch <- received{header: r.Header, body: body}
+		w.WriteHeader(status)
+		io.WriteString(w, http.StatusText(status))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, ch
+}
+
+func newTestDispatcher(t testing.TB, cfg config.WebhooksConfig) (*Dispatcher, storage.CryptoTracking) {
+	t.Helper()
+	store, err := ramstore.NewRamStorage(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return newStoreDispatcher(t, cfg, store)
+}
+
+type testStore interface {
+	storage.Webhooks
+	storage.CryptoTracking
+}
+
+func newStoreDispatcher(t testing.TB, cfg config.WebhooksConfig, store testStore) (*Dispatcher, storage.CryptoTracking) {
+	t.Helper()
+	d, err := New(&config.Config{WebhooksConfig: cfg}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, d.Notifying(store)
+}
+
+func TestSignedDelivery(t *testing.T) {
+	srv, ch := receiver(t, http.StatusNoContent)
+	d, store := newTestDispatcher(t, config.WebhooksConfig{PollInterval: time.Hour, AllowPrivate: true})
+	hook, err := d.Create(storage.Webhook{User: "alice", URL: srv.URL, Events: []string{EventPriceUpdated}, Symbols: []string{"BTC"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hook.Secret) != 64 || hook.Symbols[0] != "btc" {
+		t.Fatalf("unexpected webhook %+v", hook)
+	}
+	if err := d.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Shutdown(context.Background())
+
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := store.AddCrypto("eth", "Ethereum", "usd", 1, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddCrypto("btc", "Bitcoin", "usd", 42, now); err != nil {
+		t.Fatal(err)
+	}
+
+	var got received
+	select {
+	case got = <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery received")
+	}
+	if !Verify(hook.Secret, got.body, got.header.Get(HeaderSignature)) {
+		t.Fatalf("bad signature %q", got.header.Get(HeaderSignature))
+	}
+	if Verify("other", got.body, got.header.Get(HeaderSignature)) {
+		t.Fatal("signature verified with the wrong secret")
+	}
+	var payload struct {
+		Payload
+		Data PriceData `json:"data"`
+	}
+	if err := json.Unmarshal(got.body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Event != EventPriceUpdated || payload.ID != got.header.Get(HeaderDelivery) ||
+		payload.Data.Symbol != "btc" || payload.Data.Price != 42 || !payload.Data.Time.Equal(now) {
+		t.Fatalf("unexpected payload %s", got.body)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		log, err := d.Deliveries("alice", hook.ID, storage.DeliveryDelivered, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(log) == 1 && log[0].ResponseCode == http.StatusNoContent && log[0].Attempts == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("delivery not marked delivered: %+v", log)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case extra := <-ch:
+		t.Fatalf("unexpected extra delivery %s", extra.body)
+	default:
+	}
+}
+
+func TestRetryAndDeadLetter(t *testing.T) {
+	srv, ch := receiver(t, http.StatusServiceUnavailable)
+	d, _ := newTestDispatcher(t, config.WebhooksConfig{AllowPrivate: true, MaxAttempts: 3, BackoffBase: time.Second, BackoffMax: 90 * time.Second})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	hook, err := d.Create(storage.Webhook{User: "alice", URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Notify(EventCoinAdded, "btc", CoinData{ID: "bitcoin", Symbol: "btc"})
+
+	ctx := context.Background()
+	for attempt, wait := range []time.Duration{0, time.Second, 2 * time.Second} {
+		d.Flush(ctx)
+		<-ch
+		log, err := d.Deliveries("alice", hook.ID, "", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(log) != 1 || log[0].Attempts != attempt+1 || !strings.HasPrefix(log[0].LastError, "503") {
+			t.Fatalf("attempt %d: unexpected log %+v", attempt+1, log)
+		}
+		if attempt < 2 && (log[0].Status != storage.DeliveryPending || !log[0].NextAttempt.Equal(now.Add(d.backoff(attempt+1)))) {
+			t.Fatalf("attempt %d: retry not scheduled: %+v", attempt+1, log[0])
+		}
+		d.Flush(ctx)
+		if len(ch) != 0 {
+			t.Fatalf("attempt %d: retried before the backoff elapsed", attempt+1)
+		}
+		now = now.Add(wait + 2*time.Second)
+	}
+	dead, err := d.Deliveries("alice", hook.ID, storage.DeliveryDead, 0)
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("expected a dead-lettered delivery, got %+v, %v", dead, err)
+	}
+	d.Flush(ctx)
+	if len(ch) != 0 {
+		t.Fatal("dead delivery was retried")
+	}
+	if got := d.backoff(10); got != 90*time.Second {
+		t.Fatalf("backoff not capped: %s", got)
+	}
+}
+
+type healths map[string]priceUpdater.Health
+
+func (h healths) GetHealths() map[string]priceUpdater.Health {
+	return h
+}
+
+func TestTrackingAndHealthEvents(t *testing.T) {
+	srv, _ := receiver(t, http.StatusOK)
+	d, store := newTestDispatcher(t, config.WebhooksConfig{AllowPrivate: true})
+	hook, err := d.Create(storage.Webhook{User: "alice", URL: srv.URL,
+		Events: []string{EventCoinAdded, EventCoinDeleted, EventFeedFailing, EventFeedRecovered}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coin := storage.TrackedCoin{ID: "bitcoin", Symbol: "btc", Name: "Bitcoin", Enabled: true}
+	if err := store.SaveTrackedCoin(coin); err != nil {
+		t.Fatal(err)
+	}
+	coin.Enabled = false
+	if err := store.SaveTrackedCoin(coin); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeleteTrackedCoin("bitcoin"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddCrypto("btc", "Bitcoin", "usd", 1, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	source := healths{"btc": {Status: priceUpdater.StatusFailing, LastError: "timeout", ConsecutiveFailures: 1}}
+	d.WatchHealth(source)
+	d.CheckHealth()
+	d.CheckHealth()
+	source["btc"] = priceUpdater.Health{Status: priceUpdater.StatusOK}
+	d.CheckHealth()
+
+	log, err := d.Deliveries("alice", hook.ID, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	for i := len(log) - 1; i >= 0; i-- {
+		events = append(events, log[i].Event)
+	}
+	want := []string{EventCoinAdded, EventCoinDeleted, EventFeedFailing, EventFeedRecovered}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Fatalf("got events %v, want %v", events, want)
+	}
+}
+
+func TestCreateValidation(t *testing.T) {
+	d, _ := newTestDispatcher(t, config.WebhooksConfig{MaxPerUser: 1})
+	for _, hook := range []storage.Webhook{
+		{User: "alice", URL: "ftp://example.com"},
+		{User: "alice", URL: "/relative"},
+		{User: "alice", URL: "http://example.com", Events: []string{"price.deleted"}},
+		{User: "alice", URL: "http://127.0.0.1:8080/hook"},
+		{User: "alice", URL: "http://[::1]/hook"},
+		{User: "alice", URL: "http://10.1.2.3/hook"},
+		{User: "alice", URL: "http://169.254.169.254/latest/meta-data"},
+	} {
+		if _, err := d.Create(hook); !errors.Is(err, ErrInvalidWebhook) {
+			t.Fatalf("%+v: got %v, want ErrInvalidWebhook", hook, err)
+		}
+	}
+	hook, err := d.Create(storage.Webhook{User: "alice", URL: "http://example.com", Secret: "mine"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hook.Secret != "mine" || len(hook.Events) != len(Events) {
+		t.Fatalf("unexpected webhook %+v", hook)
+	}
+	if _, err := d.Create(storage.Webhook{User: "alice", URL: "http://example.com"}); !errors.Is(err, ErrTooManyWebhooks) {
+		t.Fatalf("got %v, want ErrTooManyWebhooks", err)
+	}
+	if _, err := d.Deliveries("bob", hook.ID, "", 0); !errors.Is(err, storage.ErrWebhookNotExists) {
+		t.Fatalf("foreign log: got %v, want ErrWebhookNotExists", err)
+	}
+	if err := d.Delete("alice", hook.ID); err != nil {
+		t.Fatal(err)
+	}
+	if hooks := d.Webhooks("alice"); len(hooks) != 0 {
+		t.Fatalf("webhook not deleted: %+v", hooks)
+	}
+}
+
+func TestPrivateTargetRefusedAtDial(t *testing.T) {
+	srv, ch := receiver(t, http.StatusOK)
+	d, _ := newTestDispatcher(t, config.WebhooksConfig{})
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Host = "localhost:" + u.Port()
+	hook, err := d.Create(storage.Webhook{User: "alice", URL: u.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Notify(EventCoinAdded, "btc", CoinData{ID: "bitcoin", Symbol: "btc"})
+	d.Flush(context.Background())
+
+	if len(ch) != 0 {
+		t.Fatal("delivery reached a loopback receiver")
+	}
+	log, err := d.Deliveries("alice", hook.ID, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 1 || !strings.Contains(log[0].LastError, errForbiddenTarget.Error()) {
+		t.Fatalf("unexpected log %+v", log)
+	}
+}
+
+func TestOrphanedDeliveriesMarkedDead(t *testing.T) {
+	d, _ := newTestDispatcher(t, config.WebhooksConfig{Workers: 1})
+	hook, err := d.Create(storage.Webhook{User: "alice", URL: "http://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		d.Notify(EventCoinAdded, "btc", CoinData{ID: "bitcoin", Symbol: "btc"})
+	}
+	d.mu.Lock()
+	delete(d.hooks, hook.ID)
+	d.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	d.Flush(ctx)
+	if ctx.Err() != nil {
+		t.Fatal("flush kept re-reading orphaned deliveries")
+	}
+	dead, err := d.store.GetDeliveries(hook.ID, storage.DeliveryDead, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 4 || dead[0].Attempts != 0 {
+		t.Fatalf("orphaned deliveries not marked dead: %+v", dead)
+	}
+}
+
+func BenchmarkNotify(b *testing.B) {
+	stores := map[string]func(b *testing.B) testStore{
+		"ram": func(b *testing.B) testStore {
+			store, err := ramstore.NewRamStorage(&config.Config{})
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Cleanup(func() { store.Close() })
+			return store
+		},
+		"sqlite": func(b *testing.B) testStore {
+			store, err := sqliteStorage.NewSQLiteStorage(&config.Config{
+				SQLiteConfig: config.SQLiteConfig{Path: filepath.Join(b.TempDir(), "bench.db")},
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Cleanup(func() { store.Close() })
+			return store
+		},
+	}
+	for name, open := range stores {
+		for _, hooks := range []int{1, 10, 100} {
+			b.Run(fmt.Sprintf("%s/%d", name, hooks), func(b *testing.B) {
+				d, _ := newStoreDispatcher(b, config.WebhooksConfig{MaxPerUser: hooks}, open(b))
+				for i := 0; i < hooks; i++ {
+					if _, err := d.Create(storage.Webhook{User: "alice", URL: "https://example.com/hook"}); err != nil {
+						b.Fatal(err)
+					}
+				}
+				data := PriceData{Symbol: "btc", Name: "Bitcoin", Currency: "usd", Price: 100, Time: time.Now()}
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					d.Notify(EventPriceUpdated, "btc", data)
+				}
+			})
+		}
+	}
+}